@@ -0,0 +1,85 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"github.com/alanwgt/jsync/internal/api"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/log"
+	"github.com/spf13/cobra"
+	"net/http"
+	"time"
+)
+
+var apiAddr string
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve os dados sincronizados numa API REST e GraphQL somente leitura",
+	Long: `Inicia um servidor HTTP que serve os recursos sincronizados no banco como JSON:
+
+  GET /properties, /properties/{id}, /condominiums, /brokers e /banners (e /{recurso}/{id})
+
+As listagens aceitam os parâmetros page, per_page (máximo 100), sort (colunas separadas por vírgula, com o prefixo "-"
+para ordem decrescente) e os filtros type, city, neighborhood, contract, min_price, max_price, bedrooms e
+min_bedrooms. Apenas os imóveis ativos são servidos.
+
+Consultas GraphQL são respondidas em /graphql, com um schema gerado a partir das colunas mapeadas de cada recurso.
+
+Se houver chaves configuradas em api.keys, a chave deve ser enviada no cabeçalho X-Api-Key (ou Authorization: Bearer).
+Em ambientes multi tenancy, o tenant é o da chave ou, se a chave não tiver tenant, o do cabeçalho X-Tenant.`,
+	Example: "jsync api --addr :8080",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !jSync.Db().Configured() {
+			return errors.New("a API lê os dados sincronizados no banco, configure a connection_string")
+		}
+
+		addr := apiAddr
+		if addr == "" {
+			addr = cfg.Api.Addr
+		}
+
+		if addr == "" {
+			addr = config.DefaultApiAddr
+		}
+
+		srv := &http.Server{
+			Addr:              addr,
+			Handler:           api.New(*jSync, cfg.Api),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		log.Info().Str("addr", addr).Msg("API iniciada")
+		return srv.ListenAndServe()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+
+	apiCmd.Flags().StringVar(&apiAddr, "addr", "", "endereço em que o servidor escuta (padrão é api.addr ou :8080)")
+}
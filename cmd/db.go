@@ -27,7 +27,7 @@ package cmd
 
 import (
 	"database/sql"
-	"github.com/doug-martin/goqu/v9"
+	"github.com/alanwgt/jsync/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -38,52 +38,47 @@ var dbCmd = &cobra.Command{
 }
 
 var dbClearCmd = &cobra.Command{
-	Use:   "clear",
-	Short: "Limpa todas as tabelas",
+	Use:   "clear [recursos...]",
+	Short: "Remove os dados das tabelas",
+	Long: `Remove os dados dos recursos fornecidos (banners, brokers, condominiums e properties). Se nenhum for fornecido,
+todos os recursos são limpos.
+
+Em ambientes multi tenancy, apenas os dados dos tenants configurados são removidos. Utilize a flag --tenant para
+limpar apenas os dados de um tenant.`,
+	ValidArgs: config.Resources,
+	Args:      cobra.OnlyValidArgs,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		ignoreDbLock = true
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := jSync.Db().ExecInTx(func(tx *sql.Tx) error {
-			truncate := func(table string) error {
-				s, _, err := goqu.Truncate(table).Cascade().ToSQL()
-				if err != nil {
-					return err
-				}
-
-				_, err = tx.Exec(s)
-				return err
-			}
-
-			var err error
-			if err = truncate(jSync.GetBannersTable()); err != nil {
-				return err
-			}
-
-			if err = truncate(jSync.GetCondominiumsTable()); err != nil {
-				return err
-			}
-
-			if err = truncate(jSync.GetPropertiesTable()); err != nil {
-				return err
-			}
-
-			if err = truncate(jSync.GetBrokersTable()); err != nil {
-				return err
-			}
+		if err := confirm("os dados serão removidos permanentemente, continuar?"); err != nil {
+			return err
+		}
 
-			return nil
+		return jSync.Db().ExecInTx(func(tx *sql.Tx) error {
+			return jSync.ClearResources(tx, args...)
 		})
+	},
+}
 
-		if err == nil {
-			jSync.L.Info().Msg("tabelas truncadas")
-		}
+var dbCheckCmd = &cobra.Command{
+	Use:   "check [recursos...]",
+	Short: "Valida o mapeamento das colunas contra as tabelas do banco de dados",
+	Long: `Compara os campos de cada recurso e o mapeamento configurado com as colunas das tabelas (via information_schema),
+reportando colunas inexistentes, tipos incompatíveis e colunas NOT NULL que receberão valores nulos.
 
-		return nil
+Os recursos disponíveis são: banners, brokers, condominiums e properties. Se nenhum for fornecido, todos são validados.`,
+	ValidArgs: config.Resources,
+	Args:      cobra.OnlyValidArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return jSync.ForEachTenant(func() error {
+			return jSync.ValidateSchema(args...)
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(dbCmd)
 	dbCmd.AddCommand(dbClearCmd)
+	dbCmd.AddCommand(dbCheckCmd)
 }
@@ -0,0 +1,169 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"github.com/alanwgt/jsync/log"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+var migrateAll bool
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Executa as migrações embutidas no executável",
+	Long: `As migrações são as mesmas disponíveis na pasta "migrations" do repositório e são embutidas no executável, não
+sendo necessário acesso à rede. Os nomes das tabelas respeitam as configurações "mappings.*_table" e a coluna de
+tenant respeita a configuração "tenant_column". Após cada "up" bem sucedido, as tabelas filhas opcionais configuradas
+(como "mappings.season_calendar_table") de cada tenant são criadas se ainda não existirem, e as chaves estrangeiras das
+tabelas de imóveis são criadas se "integrity.foreign_keys" estiver habilitado, ou removidas caso contrário.
+
+A versão aplicada é salva na tabela "schema_migrations", compatível com o golang-migrate.`,
+}
+
+var dbMigrateUpCmd = &cobra.Command{
+	Use:   "up [n]",
+	Short: "Aplica as migrações pendentes (todas, ou as n próximas)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := migrationSteps(args)
+		if err != nil {
+			return err
+		}
+
+		m, err := jSync.NewMigrator()
+		if err != nil {
+			return err
+		}
+
+		if err = m.Up(n); err != nil {
+			return err
+		}
+
+		// tenants com tabelas próprias têm as suas chaves, tenants que compartilham as tabelas são aplicados uma vez
+		applied := make(map[string]bool)
+		return jSync.ForEachTenant(func() error {
+			if err := jSync.ApplyChildTables(); err != nil {
+				return err
+			}
+
+			table := jSync.GetPropertiesTable()
+			if applied[table] {
+				return nil
+			}
+
+			applied[table] = true
+			return jSync.ApplyForeignKeys()
+		})
+	},
+}
+
+var dbMigrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Reverte a última migração aplicada (ou as n últimas)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := migrationSteps(args)
+		if err != nil {
+			return err
+		}
+
+		if migrateAll {
+			n = 0
+		} else if n == 0 {
+			n = 1
+		}
+
+		m, err := jSync.NewMigrator()
+		if err != nil {
+			return err
+		}
+
+		return m.Down(n)
+	},
+}
+
+var dbMigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Lista as migrações disponíveis e se já foram aplicadas",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := jSync.NewMigrator()
+		if err != nil {
+			return err
+		}
+
+		ss, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, s := range ss {
+			log.Info().Uint("version", s.Version).Str("name", s.Name).Bool("applied", s.Applied).Msg("migração")
+		}
+
+		return nil
+	},
+}
+
+var dbMigrateVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Exibe a versão atual do banco de dados",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := jSync.NewMigrator()
+		if err != nil {
+			return err
+		}
+
+		v, dirty, err := m.Version()
+		if err != nil {
+			return err
+		}
+
+		log.Info().Uint("version", v).Bool("dirty", dirty).Msg("versão do banco de dados")
+		return nil
+	},
+}
+
+func migrationSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, errors.New("o número de migrações precisa ser um inteiro positivo")
+	}
+
+	return n, nil
+}
+
+func init() {
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbMigrateCmd.AddCommand(dbMigrateUpCmd, dbMigrateDownCmd, dbMigrateStatusCmd, dbMigrateVersionCmd)
+	dbMigrateDownCmd.Flags().BoolVar(&migrateAll, "all", false, "reverte todas as migrações aplicadas")
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"github.com/alanwgt/jsync/internal/jsync"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var schemaDialect string
+var schemaTenantType string
+var schemaOutput string
+
+var dbSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Gera o DDL das tabelas a partir do mapeamento configurado",
+	Long: `Gera os comandos CREATE TABLE de banners, corretores, condomínios e imóveis a partir dos campos de cada recurso e
+do mapeamento configurado (nomes de tabelas, colunas e coluna de tenant), garantindo que o banco de um novo site
+esteja sempre de acordo com a sua configuração.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ddl, err := jSync.GenerateSchema(schemaDialect, schemaTenantType)
+		if err != nil {
+			return err
+		}
+
+		if schemaOutput == "" {
+			_, err = cmd.OutOrStdout().Write([]byte(ddl))
+			return err
+		}
+
+		return os.WriteFile(schemaOutput, []byte(ddl), 0644)
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbSchemaCmd)
+	dbSchemaCmd.Flags().StringVar(&schemaDialect, "dialect", jsync.DialectPostgres, "dialeto SQL do DDL gerado")
+	dbSchemaCmd.Flags().StringVar(&schemaTenantType, "tenant-type", "INT", "tipo SQL da coluna de tenant (apenas multi tenancy)")
+	dbSchemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "arquivo de saída (padrão é a saída padrão)")
+}
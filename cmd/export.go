@@ -0,0 +1,125 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/export"
+	"github.com/spf13/cobra"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var exportFormat string
+var exportOutput string
+
+var exportCmd = &cobra.Command{
+	Use:   "export [recurso]",
+	Short: "Exporta os dados sincronizados em outros formatos",
+	Long: `Exporta um recurso (banners, brokers, condominiums ou properties) da Jetimob para arquivos CSV, NDJSON ou
+Parquet, com os nomes de colunas e as transformações do mapeamento, sem utilizar o banco de dados. A connection string
+do banco (db.connection_string) pode ser omitida da configuração.
+
+O arquivo padrão é <recurso>.<formato>. Em ambientes multi tenancy, um arquivo é gerado por tenant, com o
+identificador do tenant no nome (ex.: properties_1.csv), a não ser que o tenant seja informado com a flag --tenant.`,
+	Example: `jsync export properties --format parquet
+jsync export brokers -f csv -o corretores.csv`,
+	ValidArgs: config.Resources,
+	Args:      cobra.ExactValidArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		valid := false
+		for _, f := range export.Formats {
+			valid = valid || f == exportFormat
+		}
+
+		if !valid {
+			return errors.New(fmt.Sprintf(`formato "%s" desconhecido, utilize %s`, exportFormat, strings.Join(export.Formats, ", ")))
+		}
+
+		if len(cfg.TenantMapping) == 0 && (cfg.WebserviceKey == nil || *cfg.WebserviceKey == "") {
+			return errors.New("a chave de webservice precisa ser especificada")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resource := args[0]
+		output := exportOutput
+		if output == "" {
+			output = resource + "." + exportFormat
+		}
+
+		tenants := jSync.GetTenants()
+		for _, t := range tenants {
+			jSync.SetCurrentTenant(t)
+			cols, rows, err := jSync.Export(resource)
+			if err != nil {
+				return err
+			}
+
+			path := output
+			if len(tenants) > 1 {
+				ext := filepath.Ext(path)
+				path = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(path, ext), t.Identifier, ext)
+			}
+
+			if err = writeExport(path, cols, rows); err != nil {
+				return err
+			}
+
+			jSync.L.Info().Str("path", path).Int("rows", len(rows)).Msg("arquivo exportado")
+		}
+
+		return nil
+	},
+}
+
+func writeExport(path string, cols []export.Column, rows [][]any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = export.Write(f, exportFormat, cols, rows); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.PersistentFlags().IntVarP(&maxPages, "max-pages", "m", math.MaxInt, "número máximo de páginas requisitadas por recurso (útil para testes)")
+	exportCmd.PersistentFlags().IntVar(&concurrentRequests, "concurrent-requests", 5, "máximo de requisições em paralelo (máximo 5)")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", export.FormatCsv, "formato do arquivo: csv, ndjson ou parquet")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "arquivo de saída (padrão é <recurso>.<formato>)")
+}
@@ -0,0 +1,140 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/feed"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/log"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+const (
+	sourceApi = "api"
+	sourceDb  = "db"
+)
+
+var feedFormat string
+var feedSource string
+var feedOutput string
+var feedInactive bool
+var feedSkipValidation bool
+
+var exportFeedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Gera o feed XML dos imóveis para os portais (VivaReal/ZAP ou OLX)",
+	Long: `Gera o feed XML dos imóveis nos formatos VRSync (VivaReal/ZAP Imóveis) ou de carga da OLX. Os imóveis são
+buscados na Jetimob (--source api) ou lidos do banco sincronizado (--source db). Apenas os preços e as partes do
+endereço marcados como visíveis na Jetimob são publicados.
+
+O feed é validado contra o schema XSD do formato, distribuído com o jsync, se o xmllint estiver instalado.
+
+Em ambientes multi tenancy, o tenant do feed deve ser informado com a flag --tenant.`,
+	Example: "jsync export feed --format vrsync --tenant 1 -o feed.xml",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateFeedFormat(); err != nil {
+			return err
+		}
+
+		if feedSource != sourceApi && feedSource != sourceDb {
+			return errors.New(fmt.Sprintf(`origem "%s" desconhecida, utilize %s ou %s`, feedSource, sourceApi, sourceDb))
+		}
+
+		if len(cfg.TenantMapping) > 0 && tenantId == "" {
+			return errors.New("informe o tenant do feed com a flag --tenant")
+		}
+
+		if feedSource == sourceApi && len(cfg.TenantMapping) == 0 && (cfg.WebserviceKey == nil || *cfg.WebserviceKey == "") {
+			return errors.New("a chave de webservice precisa ser especificada")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return jSync.ForEachTenant(func() error {
+			var ps []model.Property
+			var err error
+			if feedSource == sourceDb {
+				ps, err = jSync.ReadProperties(!feedInactive)
+			} else {
+				ps, err = jSync.FetchProperties(!feedInactive)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			data, err := feed.Build(feedFormat, ps, feed.Options{
+				Feed:        jSync.FeedConfig(),
+				Coordinates: func(p model.Property) (float64, float64, bool) { return jSync.Coordinates(p) },
+			})
+			if err != nil {
+				return err
+			}
+
+			if !feedSkipValidation {
+				err = feed.Validate(feedFormat, data)
+				if errors.Is(err, feed.ErrValidatorNotFound) {
+					log.Warn().Msg(err.Error())
+				} else if err != nil {
+					return err
+				}
+			}
+
+			jSync.L.Info().Str("format", feedFormat).Int("properties", len(ps)).Msg("feed gerado")
+
+			if feedOutput == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+
+			return os.WriteFile(feedOutput, data, 0644)
+		})
+	},
+}
+
+func validateFeedFormat() error {
+	for _, f := range feed.Formats {
+		if f == feedFormat {
+			return nil
+		}
+	}
+
+	return errors.New(fmt.Sprintf(`formato "%s" desconhecido, utilize %s`, feedFormat, strings.Join(feed.Formats, " ou ")))
+}
+
+func init() {
+	exportCmd.AddCommand(exportFeedCmd)
+	exportFeedCmd.Flags().StringVarP(&feedFormat, "format", "f", feed.FormatVrsync, "formato do feed: vrsync ou olx")
+	exportFeedCmd.Flags().StringVar(&feedSource, "source", sourceApi, "origem dos imóveis: api (Jetimob) ou db (banco sincronizado)")
+	exportFeedCmd.Flags().StringVarP(&feedOutput, "output", "o", "", "arquivo de saída (padrão é a saída padrão)")
+	exportFeedCmd.Flags().BoolVar(&feedInactive, "include-inactive", false, "inclui os imóveis inativos no feed")
+	exportFeedCmd.Flags().BoolVar(&feedSkipValidation, "skip-validation", false, "não valida o feed contra o schema XSD")
+}
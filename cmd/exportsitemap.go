@@ -0,0 +1,149 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/feed"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var sitemapBaseURL string
+var sitemapURL string
+var sitemapSource string
+var sitemapOutputDir string
+var sitemapSkipJsonLd bool
+
+var exportSitemapCmd = &cobra.Command{
+	Use:   "sitemap",
+	Short: "Gera o sitemap e os dados estruturados (JSON-LD) das páginas dos imóveis",
+	Long: `Gera o sitemap.xml das páginas dos imóveis ativos, com a data de atualização e as imagens de cada imóvel, e um
+arquivo JSON-LD (schema.org RealEstateListing) por imóvel em <diretório>/jsonld/<id>.json. Apenas os preços e as
+partes do endereço marcados como visíveis na Jetimob são publicados nos dados estruturados.
+
+A URL das páginas é informada com a flag --base-url, com os marcadores {codigo} e/ou {id}. Se houver mais de 50.000
+imóveis, o sitemap é dividido em arquivos sitemap-N.xml e o sitemap.xml passa a ser o índice desses arquivos, que devem
+ser publicados na URL informada em --sitemap-url (padrão é a raiz do domínio de --base-url). Se mais de um imóvel gerar
+a mesma URL, como imóveis com o mesmo código, o comando falha informando os imóveis.
+
+Em ambientes multi tenancy, o tenant deve ser informado com a flag --tenant.`,
+	Example: "jsync export sitemap --base-url https://site/imovel/{codigo} -o public",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if sitemapBaseURL == "" {
+			return errors.New("informe a URL das páginas dos imóveis com a flag --base-url")
+		}
+
+		if sitemapSource != sourceApi && sitemapSource != sourceDb {
+			return errors.New(fmt.Sprintf(`origem "%s" desconhecida, utilize %s ou %s`, sitemapSource, sourceApi, sourceDb))
+		}
+
+		if len(cfg.TenantMapping) > 0 && tenantId == "" {
+			return errors.New("informe o tenant do sitemap com a flag --tenant")
+		}
+
+		if sitemapSource == sourceApi && len(cfg.TenantMapping) == 0 && (cfg.WebserviceKey == nil || *cfg.WebserviceKey == "") {
+			return errors.New("a chave de webservice precisa ser especificada")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return jSync.ForEachTenant(func() error {
+			var ps []model.Property
+			var err error
+			if sitemapSource == sourceDb {
+				ps, err = jSync.ReadProperties(true)
+			} else {
+				ps, err = jSync.FetchProperties(true)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			opts := feed.Options{
+				Feed:        jSync.FeedConfig(),
+				Coordinates: func(p model.Property) (float64, float64, bool) { return jSync.Coordinates(p) },
+				PropertyURL: sitemapBaseURL,
+				SitemapURL:  sitemapURL,
+			}
+
+			files, err := feed.BuildSitemaps(ps, opts)
+			if err != nil {
+				return err
+			}
+
+			if err = os.MkdirAll(sitemapOutputDir, 0755); err != nil {
+				return err
+			}
+
+			for _, f := range files {
+				if err = os.WriteFile(filepath.Join(sitemapOutputDir, f.Name), f.Data, 0644); err != nil {
+					return err
+				}
+			}
+
+			jSync.L.Info().Int("properties", len(ps)).Int("files", len(files)).Msg("sitemap gerado")
+
+			if sitemapSkipJsonLd {
+				return nil
+			}
+
+			dir := filepath.Join(sitemapOutputDir, "jsonld")
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+
+			for _, p := range ps {
+				data, err := feed.StructuredData(p, opts)
+				if err != nil {
+					return err
+				}
+
+				if err = os.WriteFile(filepath.Join(dir, strconv.Itoa(p.Id)+".json"), data, 0644); err != nil {
+					return err
+				}
+			}
+
+			jSync.L.Info().Int("properties", len(ps)).Str("path", dir).Msg("dados estruturados gerados")
+			return nil
+		})
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportSitemapCmd)
+	exportSitemapCmd.Flags().StringVar(&sitemapBaseURL, "base-url", "", "URL das páginas dos imóveis, com os marcadores {codigo} e/ou {id}")
+	exportSitemapCmd.Flags().StringVar(&sitemapURL, "sitemap-url", "", "URL onde os arquivos do sitemap são publicados (padrão é a raiz do domínio de --base-url)")
+	exportSitemapCmd.Flags().StringVar(&sitemapSource, "source", sourceApi, "origem dos imóveis: api (Jetimob) ou db (banco sincronizado)")
+	exportSitemapCmd.Flags().StringVarP(&sitemapOutputDir, "output", "o", ".", "diretório de saída")
+	exportSitemapCmd.Flags().BoolVar(&sitemapSkipJsonLd, "skip-jsonld", false, "não gera os arquivos JSON-LD dos imóveis")
+}
@@ -28,6 +28,7 @@ package cmd
 // root
 var cfgFile string
 var verbose bool
+var assumeYes bool
 
 // sync
 var ignoreLastSync bool
@@ -37,3 +38,4 @@ var concurrentRequests int
 var truncate bool
 var preHook string
 var postHook string
+var skipSchemaCheck bool
@@ -0,0 +1,122 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/lint"
+	"github.com/spf13/cobra"
+	"math"
+	"strings"
+)
+
+const (
+	lintFormatTable = "table"
+	lintFormatJson  = "json"
+)
+
+var lintFormat string
+var lintInactive bool
+var lintFailOn string
+var linter lint.Linter
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Verifica a qualidade dos anúncios dos imóveis e condomínios",
+	Long: `Busca os imóveis e condomínios na Jetimob, aplicando os filtros da sincronização, e executa regras de qualidade
+que explicam por que um anúncio é mal exibido nos sites e portais. Os problemas encontrados são listados por tenant,
+como tabela ou JSON (--format json).
+
+A severidade das regras pode ser alterada em lint.rules (error, warning, info ou off). Com a flag --fail-on, o comando
+termina com erro se algum problema tiver a severidade informada ou uma mais grave, para uso em pipelines.
+
+Regras disponíveis (severidade padrão):
+`,
+	Example: "jsync lint --format json --fail-on error",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if lintFormat != lintFormatTable && lintFormat != lintFormatJson {
+			return errors.New(fmt.Sprintf(`formato "%s" desconhecido, utilize %s ou %s`, lintFormat, lintFormatTable, lintFormatJson))
+		}
+
+		if lintFailOn != "" && !lint.ValidSeverity(lintFailOn) {
+			return errors.New(fmt.Sprintf(`severidade "%s" desconhecida, utilize error, warning ou info`, lintFailOn))
+		}
+
+		if len(cfg.TenantMapping) == 0 && (cfg.WebserviceKey == nil || *cfg.WebserviceKey == "") {
+			return errors.New("a chave de webservice precisa ser especificada")
+		}
+
+		var err error
+		linter, err = lint.New(cfg.Lint)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var reports []lint.Report
+		err := jSync.ForEachTenant(func() error {
+			r, err := jSync.Lint(linter, !lintInactive)
+			reports = append(reports, r)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if lintFormat == lintFormatJson {
+			err = lint.WriteJSON(cmd.OutOrStdout(), reports)
+		} else {
+			err = lint.WriteTable(cmd.OutOrStdout(), reports)
+		}
+
+		if err != nil || lintFailOn == "" {
+			return err
+		}
+
+		for _, r := range reports {
+			if r.Has(lintFailOn) {
+				return errors.New(fmt.Sprintf("foram encontrados problemas com severidade %s ou mais grave", lintFailOn))
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	var rules strings.Builder
+	for _, r := range lint.Rules {
+		rules.WriteString(fmt.Sprintf("  %-20s %s (%s)\n", r.Id, r.Description, r.Severity))
+	}
+
+	lintCmd.Long += strings.TrimSuffix(rules.String(), "\n")
+
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().IntVarP(&maxPages, "max-pages", "m", math.MaxInt, "número máximo de páginas requisitadas por recurso (útil para testes)")
+	lintCmd.Flags().IntVar(&concurrentRequests, "concurrent-requests", 5, "máximo de requisições em paralelo (máximo 5)")
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", lintFormatTable, "formato da saída: table ou json")
+	lintCmd.Flags().BoolVar(&lintInactive, "include-inactive", false, "verifica também os imóveis inativos")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "", "termina com erro se houver problemas com esta severidade ou mais grave (error, warning ou info)")
+}
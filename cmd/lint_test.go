@@ -0,0 +1,84 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package cmd
+
+import (
+	"fmt"
+	jhttp "github.com/alanwgt/jsync/internal/http"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestLintFetchesAllPages garante que, com os valores padrão das flags do lint, todas as páginas dos imóveis são
+// requisitadas, e não apenas a primeira.
+func TestLintFetchesAllPages(t *testing.T) {
+	flagValue := func(name string) int {
+		f := lintCmd.Flags().Lookup(name)
+		if f == nil {
+			t.Fatalf("o comando lint não registra a flag --%s", name)
+		}
+
+		n, err := strconv.Atoi(f.DefValue)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return n
+	}
+
+	const totalPages = 7
+	transport := http.DefaultTransport
+	t.Cleanup(func() { http.DefaultTransport = transport })
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := fmt.Sprintf(`{"pageSize": 1, "totalPages": %d, "data": [{"id_imovel": %s, "rural": null}]}`, totalPages, r.URL.Query().Get("page"))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+
+	ps, err := jhttp.NewRequester(flagValue("max-pages"), flagValue("concurrent-requests")).GetProperties(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int]bool)
+	for _, p := range ps {
+		seen[p.Id] = true
+	}
+
+	for page := 1; page <= totalPages; page++ {
+		if !seen[page] {
+			t.Errorf("a página %d não foi requisitada (%d de %d imóveis)", page, len(ps), totalPages)
+		}
+	}
+}
@@ -102,6 +102,7 @@ Use "{{.CommandPath}} [comando] --help" para mais informações.{{end}}
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "aumenta a verbosidade dos logs")
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "arquivo de configuração (padrão é $HOME/.jsync.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&tenantId, "tenant", "t", "", "faz a sincronização apenas para o id do tenant fornecido")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "confirma automaticamente operações destrutivas")
 
 	cobra.OnInitialize(initConfig)
 }
@@ -157,10 +158,10 @@ func initConfig() {
 
 	cfg.CmdCfg = config.CmdCfg{
 		TenantId:           tenantId,
-		IgnoreLastSync:     ignoreLastSync,
+		IgnoreLastSync:     ignoreLastSync || cfg.TruncateAll, // com as tabelas truncadas, todos os imóveis precisam ser buscados
 		MaxPages:           maxPages,
 		ConcurrentRequests: concurrentRequests,
-		Truncate:           truncate,
+		Truncate:           truncate || cfg.TruncateAll,
 	}
 
 	var lvl zerolog.Level
@@ -27,6 +27,7 @@ package cmd
 
 import (
 	"errors"
+	"github.com/alanwgt/jsync/internal/config"
 	"github.com/alanwgt/jsync/internal/shell"
 	"github.com/alanwgt/jsync/log"
 	"github.com/spf13/cobra"
@@ -39,11 +40,19 @@ var syncCmd = &cobra.Command{
 	Aliases: []string{"s"},
 	Short:   "Sincroniza recursos da jetimob com o banco de dados local",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if !ignoreDbLock && truncate && cfg.LastSync != nil && !ignoreLastSync {
+		if !ignoreDbLock && cfg.CmdCfg.Truncate && cfg.LastSync != nil && !cfg.CmdCfg.IgnoreLastSync {
 			return errors.New(`se as tabelas forem truncadas e houver uma data de sincronização anterior, dados serão perdidos!
 Remova a opção "truncate", ou utilize a flag --ignore-last-sync para buscar todos os imóveis ignorando a data de sincronização`)
 		}
 
+		// truncate_all já é uma opção explícita da configuração, então apenas a flag --truncate em execuções interativas
+		// precisa ser confirmada
+		if truncate && interactive() {
+			if err := confirm("os dados dos tenants sincronizados serão removidos das tabelas antes da sincronização, continuar?"); err != nil {
+				return err
+			}
+		}
+
 		if cfg.TenantMapping != nil {
 			if len(cfg.TenantMapping) == 0 && cfg.WebserviceKey == nil {
 				return errors.New("a chave de webservice ou o mapeamento de tenants deve ser configurado")
@@ -52,10 +61,6 @@ Remova a opção "truncate", ou utilize a flag --ignore-last-sync para buscar to
 			if len(cfg.TenantMapping) > 0 && cfg.WebserviceKey != nil && *cfg.WebserviceKey != "" {
 				return errors.New("a chave de webservice OU o mapeamento de tenants deve ser configurado, NÃO os dois")
 			}
-
-			if len(cfg.TenantMapping) > 0 && cfg.TenantDiscriminatorColumn == nil || *cfg.TenantDiscriminatorColumn == "" {
-				return errors.New("a coluna discriminatória precisa estar configurada para ambiente multi tenancy")
-			}
 		} else if cfg.WebserviceKey == nil {
 			return errors.New("a chave de webservice precisa ser especificada")
 		}
@@ -68,10 +73,24 @@ Remova a opção "truncate", ou utilize a flag --ignore-last-sync para buscar to
 
 		if preHook != "" {
 			log.Debug().Str("pre-hook", preHook).Msg("executando pre-hook")
-			return shell.Exec(preHook)
+			if err := shell.Exec(preHook); err != nil {
+				return err
+			}
 		}
 
-		return nil
+		if skipSchemaCheck {
+			return nil
+		}
+
+		// recursos escritos apenas em sinks que não são o banco de dados não possuem tabelas para validar
+		resources := jSync.DatabaseResources(syncedResources(cmd)...)
+		if len(resources) == 0 {
+			return nil
+		}
+
+		return jSync.ForEachTenant(func() error {
+			return jSync.ValidateSchema(resources...)
+		})
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 		if postHook != "" {
@@ -83,13 +102,30 @@ Remova a opção "truncate", ou utilize a flag --ignore-last-sync para buscar to
 	},
 }
 
+// syncedResources retorna os recursos sincronizados pelo subcomando. Uma lista vazia representa todos os recursos.
+func syncedResources(cmd *cobra.Command) []string {
+	switch cmd {
+	case syncPropertiesCmd:
+		return []string{config.ResourceProperties}
+	case syncCondominiumsCmd:
+		return []string{config.ResourceCondominiums}
+	case syncBrokersCmd:
+		return []string{config.ResourceBrokers}
+	case syncBannersCmd:
+		return []string{config.ResourceBanners}
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(syncCmd)
 
 	syncCmd.PersistentFlags().IntVarP(&maxPages, "max-pages", "m", math.MaxInt, "número máximo de páginas requisitadas por recurso (útil para testes)")
 	syncCmd.PersistentFlags().IntVar(&concurrentRequests, "concurrent-requests", 5, "máximo de requisições em paralelo (máximo 5)")
 	syncCmd.PersistentFlags().BoolVarP(&ignoreLastSync, "ignore-last-sync", "i", false, "ignora a data da última sincronização, forçando a atualização de todos os dados")
-	syncCmd.PersistentFlags().BoolVar(&truncate, "truncate", false, "trunca a(s) tabela(s) utilizada(s) durante a sincronização")
+	syncCmd.PersistentFlags().BoolVar(&truncate, "truncate", false, "remove os dados do tenant da(s) tabela(s) utilizada(s) durante a sincronização (confirmada interativamente quando executado num terminal)")
 	syncCmd.PersistentFlags().StringVar(&preHook, "pre-hook", "", "comando para ser executado no shell antes de iniciar a sincronização")
+	syncCmd.PersistentFlags().BoolVar(&skipSchemaCheck, "skip-schema-check", false, "não valida o mapeamento contra as tabelas do banco antes da sincronização")
 	syncCmd.PersistentFlags().StringVar(&postHook, "post-hook", "", "comando para ser executado no shell após a sincronização bem sucedida")
 }
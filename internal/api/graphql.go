@@ -0,0 +1,544 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/export"
+	"github.com/alanwgt/jsync/internal/jsync"
+	"github.com/alanwgt/jsync/log"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// graphqlNames são os nomes dos tipos e das consultas de cada recurso: tipo, item e listagem.
+var graphqlNames = map[string][3]string{
+	config.ResourceBanners:      {"Banner", "banner", "banners"},
+	config.ResourceBrokers:      {"Broker", "broker", "brokers"},
+	config.ResourceCondominiums: {"Condominium", "condominium", "condominiums"},
+	config.ResourceProperties:   {"Property", "property", "properties"},
+}
+
+var graphqlName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// graphqlJson representa as colunas json, serializadas sem conversão.
+var graphqlJson = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Valor JSON arbitrário",
+	Serialize: func(v any) any {
+		return v
+	},
+	ParseValue: func(v any) any {
+		return v
+	},
+	ParseLiteral: func(v ast.Value) any {
+		return v.GetValue()
+	},
+})
+
+var graphqlTypes = map[export.Type]graphql.Output{
+	export.TypeText:     graphql.String,
+	export.TypeInt:      graphql.Int,
+	export.TypeFloat:    graphql.Float,
+	export.TypeBool:     graphql.Boolean,
+	export.TypeTime:     graphql.DateTime,
+	export.TypeTextList: graphql.NewList(graphql.String),
+	export.TypeJson:     graphqlJson,
+}
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"endCursor":   &graphql.Field{Type: graphql.String},
+	},
+})
+
+// graphqlSchemas guarda o schema gerado para cada tenant, já que o mapeamento de colunas pode variar entre tenants.
+type graphqlSchemas struct {
+	mu      sync.Mutex
+	schemas map[string]graphql.Schema
+}
+
+// graphqlRequest é o corpo das requisições POST, no formato definido pela especificação GraphQL over HTTP.
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type graphqlContextKey struct{}
+
+// graphqlContext é o estado de uma requisição: o JSync no tenant da requisição e os itens relacionados já lidos. O
+// mutex protege apenas o mapa, as leituras do banco são feitas sem ele.
+type graphqlContext struct {
+	j       jsync.JSync
+	mu      sync.Mutex
+	related map[string]map[string]any
+}
+
+func (s *Server) serveGraphql(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, apiError{http.StatusBadRequest, "variables deve ser um objeto JSON"})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, apiError{http.StatusBadRequest, "o corpo da requisição deve ser um JSON com a query"})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, apiError{http.StatusMethodNotAllowed, "apenas requisições GET e POST são aceitas"})
+		return
+	}
+
+	if req.Query == "" {
+		writeError(w, apiError{http.StatusBadRequest, "informe a query"})
+		return
+	}
+
+	j, tenant, err := s.tenant(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	schema, err := s.schemas.get(j, tenant)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphqlContextKey{}, &graphqlContext{j: j, related: map[string]map[string]any{}})
+	res := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (g *graphqlSchemas) get(j jsync.JSync, tenant string) (graphql.Schema, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if s, ok := g.schemas[tenant]; ok {
+		return s, nil
+	}
+
+	s, err := buildSchema(j)
+	if err != nil {
+		return s, err
+	}
+
+	g.schemas[tenant] = s
+	return s, nil
+}
+
+// buildSchema gera o schema a partir das colunas mapeadas de cada recurso. Cada recurso possui uma consulta por id e
+// uma listagem paginada por cursor, com os mesmos filtros da API REST. As colunas que referenciam outros recursos
+// (como o corretor do imóvel) também são expostas como os itens relacionados.
+func buildSchema(j jsync.JSync) (graphql.Schema, error) {
+	objects := make(map[string]*graphql.Object, len(config.Resources))
+	for _, res := range config.Resources {
+		cols, err := j.CatalogColumns(res)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+
+		fields := graphql.Fields{}
+		for _, c := range cols {
+			if !graphqlName.MatchString(c.Name) || strings.HasPrefix(c.Name, "__") {
+				j.L.Warn().Str("column", c.Name).Msg("nome de coluna inválido no GraphQL, pulando")
+				continue
+			}
+
+			fields[c.Name] = &graphql.Field{Type: graphqlTypes[c.Type]}
+		}
+
+		objects[res] = graphql.NewObject(graphql.ObjectConfig{Name: graphqlNames[res][0], Fields: fields})
+	}
+
+	for _, res := range config.Resources {
+		for _, rel := range j.CatalogRelations(res) {
+			name := graphqlNames[rel.Resource][1]
+			if _, ok := objects[res].Fields()[name]; ok {
+				j.L.Warn().Str("field", name).Msg("coluna com o mesmo nome da relação, relação não será exposta")
+				continue
+			}
+
+			objects[res].AddFieldConfig(name, &graphql.Field{
+				Type:    objects[rel.Resource],
+				Resolve: relatedResolver(rel),
+			})
+		}
+	}
+
+	query := graphql.Fields{}
+	for _, res := range config.Resources {
+		res := res
+		names := graphqlNames[res]
+
+		query[names[1]] = &graphql.Field{
+			Type: objects[res],
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return readRelated(p.Context, res, p.Args["id"].(int))
+			},
+		}
+
+		args := graphql.FieldConfigArgument{
+			"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: config.DefaultApiPerPage},
+			"after": &graphql.ArgumentConfig{Type: graphql.String},
+			"sort":  &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		}
+
+		if filter := filterInput(res); filter != nil {
+			args["filter"] = &graphql.ArgumentConfig{Type: filter}
+		}
+
+		query[names[2]] = &graphql.Field{
+			Type:    connectionType(names[0], objects[res]),
+			Args:    args,
+			Resolve: listResolver(res),
+		}
+	}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+	})
+}
+
+// filterInput gera o tipo dos filtros do recurso a partir dos filtros da API REST. Retorna nil se o recurso não tiver
+// filtros.
+func filterInput(resource string) *graphql.InputObject {
+	fields := graphql.InputObjectConfigFieldMap{}
+	for param := range textFilters[resource] {
+		fields[param] = &graphql.InputObjectFieldConfig{Type: graphql.String}
+	}
+
+	if resource == config.ResourceProperties {
+		fields["contract"] = &graphql.InputObjectFieldConfig{Type: graphql.String}
+		for _, n := range numericFilters {
+			if n.integer {
+				fields[n.param] = &graphql.InputObjectFieldConfig{Type: graphql.Int}
+			} else {
+				fields[n.param] = &graphql.InputObjectFieldConfig{Type: graphql.Float}
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return graphql.NewInputObject(graphql.InputObjectConfig{Name: graphqlNames[resource][0] + "Filter", Fields: fields})
+}
+
+// connectionType gera o tipo da listagem paginada por cursor (edges, pageInfo e totalCount).
+func connectionType(name string, node *graphql.Object) *graphql.Object {
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: node},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Connection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewList(edge)},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+}
+
+// listResolver lê uma página do recurso com paginação por keyset: o cursor guarda os valores das colunas de
+// ordenação e do id do item, e a página seguinte começa no primeiro item depois dele. Os itens relacionados
+// selecionados na consulta são lidos em lote, com uma consulta por recurso.
+func listResolver(resource string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		gc := p.Context.Value(graphqlContextKey{}).(*graphqlContext)
+
+		first, _ := p.Args["first"].(int)
+		if first < 1 || first > config.MaxApiPerPage {
+			return nil, errors.New(fmt.Sprintf("first deve estar entre 1 e %d", config.MaxApiPerPage))
+		}
+
+		params := url.Values{}
+		if filter, ok := p.Args["filter"].(map[string]any); ok {
+			for k, v := range filter {
+				switch t := v.(type) {
+				case float64:
+					params.Set(k, strconv.FormatFloat(t, 'f', -1, 64))
+				default:
+					params.Set(k, fmt.Sprint(t))
+				}
+			}
+		}
+
+		fs, err := catalogFilters(gc.j, resource, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var sort []string
+		if ss, ok := p.Args["sort"].([]any); ok {
+			for _, s := range ss {
+				sort = append(sort, s.(string))
+			}
+		}
+
+		keys, err := gc.j.CatalogSortKeys(resource, sort)
+		if err != nil {
+			return nil, resolveError(err)
+		}
+
+		var after []any
+		if a, ok := p.Args["after"].(string); ok && a != "" {
+			if after, err = decodeCursor(a, len(keys)); err != nil {
+				return nil, err
+			}
+		}
+
+		// um item a mais indica se existe a página seguinte
+		q := jsync.CatalogQuery{Filters: fs, Sort: sort, Limit: first + 1, After: after}
+		docs, total, err := gc.j.ReadCatalog(resource, q)
+		if err != nil {
+			return nil, resolveError(err)
+		}
+
+		hasNext := len(docs) > first
+		if hasNext {
+			docs = docs[:first]
+		}
+
+		if err = prefetchRelated(p, gc, resource, docs); err != nil {
+			return nil, err
+		}
+
+		edges := make([]map[string]any, len(docs))
+		for i, d := range docs {
+			edges[i] = map[string]any{"cursor": encodeCursor(d, keys), "node": d}
+		}
+
+		pageInfo := map[string]any{"hasNextPage": hasNext, "endCursor": nil}
+		if len(docs) > 0 {
+			pageInfo["endCursor"] = edges[len(edges)-1]["cursor"]
+		}
+
+		return map[string]any{"edges": edges, "pageInfo": pageInfo, "totalCount": total}, nil
+	}
+}
+
+func relatedResolver(rel jsync.CatalogRelation) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		source, _ := p.Source.(map[string]any)
+		id, ok := documentId(source[rel.Column])
+		if !ok {
+			return nil, nil
+		}
+
+		return readRelated(p.Context, rel.Resource, id)
+	}
+}
+
+// documentId converte o valor de uma coluna de id lido do banco.
+func documentId(v any) (int, bool) {
+	switch t := v.(type) {
+	case int64:
+		return int(t), true
+	case float64:
+		return int(t), true
+	}
+
+	return 0, false
+}
+
+// prefetchRelated lê numa única consulta por recurso os itens relacionados aos docs que foram selecionados na
+// consulta, para que relatedResolver não precise ler um item por vez.
+func prefetchRelated(p graphql.ResolveParams, gc *graphqlContext, resource string, docs []map[string]any) error {
+	selected := make(map[string]bool)
+	for _, f := range p.Info.FieldASTs {
+		selectedFields(p.Info, f.SelectionSet, []string{"edges", "node"}, selected)
+	}
+
+	for _, rel := range gc.j.CatalogRelations(resource) {
+		if !selected[graphqlNames[rel.Resource][1]] {
+			continue
+		}
+
+		var ids []int
+		for _, d := range docs {
+			if id, ok := documentId(d[rel.Column]); ok {
+				ids = append(ids, id)
+			}
+		}
+
+		if err := loadRelated(gc, rel.Resource, ids); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectedFields adiciona a selected os nomes dos campos selecionados no caminho path (ex.: edges.node), incluindo os
+// campos dos fragmentos.
+func selectedFields(info graphql.ResolveInfo, set *ast.SelectionSet, path []string, selected map[string]bool) {
+	if set == nil {
+		return
+	}
+
+	for _, sel := range set.Selections {
+		switch t := sel.(type) {
+		case *ast.Field:
+			if len(path) == 0 {
+				selected[t.Name.Value] = true
+			} else if t.Name.Value == path[0] {
+				selectedFields(info, t.SelectionSet, path[1:], selected)
+			}
+		case *ast.InlineFragment:
+			selectedFields(info, t.SelectionSet, path, selected)
+		case *ast.FragmentSpread:
+			if def, ok := info.Fragments[t.Name.Value].(*ast.FragmentDefinition); ok {
+				selectedFields(info, def.SelectionSet, path, selected)
+			}
+		}
+	}
+}
+
+// loadRelated lê os itens com os ids que ainda não foram lidos na requisição. Os ids sem item são guardados como nil
+// para que não sejam lidos novamente.
+func loadRelated(gc *graphqlContext, resource string, ids []int) error {
+	var missing []int
+	seen := make(map[int]bool, len(ids))
+	gc.mu.Lock()
+	for _, id := range ids {
+		if _, ok := gc.related[resource+":"+strconv.Itoa(id)]; !ok && !seen[id] {
+			seen[id] = true
+			missing = append(missing, id)
+		}
+	}
+	gc.mu.Unlock()
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	items, err := gc.j.ReadCatalogIds(resource, missing)
+	if err != nil {
+		return resolveError(err)
+	}
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	for _, id := range missing {
+		gc.related[resource+":"+strconv.Itoa(id)] = items[id]
+	}
+
+	return nil
+}
+
+// readRelated lê o item com o id, reaproveitando os itens já lidos na mesma requisição.
+func readRelated(ctx context.Context, resource string, id int) (any, error) {
+	gc := ctx.Value(graphqlContextKey{}).(*graphqlContext)
+	if err := loadRelated(gc, resource, []int{id}); err != nil {
+		return nil, err
+	}
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	if doc := gc.related[resource+":"+strconv.Itoa(id)]; doc != nil {
+		return doc, nil
+	}
+
+	return nil, nil
+}
+
+// resolveError omite os detalhes de falhas internas, que são registradas no log.
+func resolveError(err error) error {
+	if errors.Is(err, jsync.ErrCatalogQuery) {
+		return err
+	}
+
+	log.Error().Err(err).Msg("falha ao atender consulta GraphQL")
+	return errors.New("erro interno")
+}
+
+// encodeCursor gera o cursor do item: os valores das colunas de ordenação keys, em JSON e base64.
+func encodeCursor(doc map[string]any, keys []string) string {
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		values[i] = doc[k]
+	}
+
+	data, _ := json.Marshal(values)
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, keys int) ([]any, error) {
+	var values []any
+	if data, err := base64.URLEncoding.DecodeString(cursor); err == nil {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		if err = d.Decode(&values); err == nil && len(values) == keys {
+			return values, nil
+		}
+	}
+
+	return nil, errors.New(fmt.Sprintf(`cursor "%s" inválido`, cursor))
+}
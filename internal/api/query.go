@@ -0,0 +1,165 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package api
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/jsync"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// textFilters relaciona os parâmetros de filtro por texto de cada recurso com os campos da Jetimob.
+var textFilters = map[string]map[string]string{
+	config.ResourceProperties: {
+		"type":         "tipo",
+		"subtype":      "subtipo",
+		"city":         "endereco_cidade",
+		"neighborhood": "endereco_bairro",
+		"state":        "endereco_estado",
+	},
+	config.ResourceCondominiums: {
+		"type":         "tipo",
+		"city":         "endereco_cidade",
+		"neighborhood": "endereco_bairro",
+	},
+	config.ResourceBrokers: {
+		"city": "cidade",
+	},
+}
+
+// priceFields relaciona os contratos da Jetimob com o campo do valor utilizado pelos filtros de preço. Sem contrato, o
+// valor de venda é utilizado.
+var priceFields = map[string]string{
+	"compra":    "valor_venda",
+	"locação":   "valor_locacao",
+	"temporada": "valor_temporada",
+}
+
+// numericFilters são os filtros numéricos dos imóveis. Os filtros de preço utilizam o campo do valor do contrato
+// filtrado (priceFields).
+var numericFilters = []struct {
+	param   string
+	field   string
+	op      string
+	integer bool
+}{
+	{"min_price", "", jsync.CatalogGte, false},
+	{"max_price", "", jsync.CatalogLte, false},
+	{"bedrooms", "dormitorios", jsync.CatalogEq, true},
+	{"min_bedrooms", "dormitorios", jsync.CatalogGte, true},
+}
+
+// listQuery converte os parâmetros da listagem (filtros, sort, page e per_page) na consulta do catálogo.
+func listQuery(j jsync.JSync, resource string, params url.Values) (jsync.CatalogQuery, int, int, error) {
+	var q jsync.CatalogQuery
+	page, err := intParam(params, "page", 1)
+	if err != nil {
+		return q, 0, 0, err
+	}
+
+	perPage, err := intParam(params, "per_page", config.DefaultApiPerPage)
+	if err != nil {
+		return q, 0, 0, err
+	}
+
+	if page < 1 || perPage < 1 || perPage > config.MaxApiPerPage {
+		return q, 0, 0, apiError{http.StatusBadRequest, "page deve ser maior que 0 e per_page deve estar entre 1 e " + strconv.Itoa(config.MaxApiPerPage)}
+	}
+
+	q.Limit = perPage
+	q.Offset = (page - 1) * perPage
+
+	if s := params.Get("sort"); s != "" {
+		q.Sort = strings.Split(s, ",")
+	}
+
+	if q.Filters, err = catalogFilters(j, resource, params); err != nil {
+		return q, 0, 0, err
+	}
+
+	return q, page, perPage, nil
+}
+
+// catalogFilters converte os parâmetros de filtro do recurso nos filtros do catálogo.
+func catalogFilters(j jsync.JSync, resource string, params url.Values) ([]jsync.CatalogFilter, error) {
+	var fs []jsync.CatalogFilter
+	for param, field := range textFilters[resource] {
+		if v := params.Get(param); v != "" {
+			fs = append(fs, jsync.CatalogFilter{Field: field, Op: jsync.CatalogEq, Value: v})
+		}
+	}
+
+	if resource != config.ResourceProperties {
+		return fs, nil
+	}
+
+	price := priceFields["compra"]
+	if v := params.Get("contract"); v != "" {
+		jetimob, local := j.Contract(v)
+		if f, ok := priceFields[jetimob]; ok {
+			price = f
+		}
+
+		fs = append(fs, jsync.CatalogFilter{Field: "contrato", Op: jsync.CatalogContains, Value: local})
+	}
+
+	for _, n := range numericFilters {
+		if params.Get(n.param) == "" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(params.Get(n.param), 64)
+		if err != nil {
+			return nil, apiError{http.StatusBadRequest, n.param + " deve ser um número"}
+		}
+
+		field := n.field
+		if field == "" {
+			field = price
+		}
+
+		fs = append(fs, jsync.CatalogFilter{Field: field, Op: n.op, Value: v})
+	}
+
+	return fs, nil
+}
+
+func intParam(params url.Values, name string, def int) (int, error) {
+	v := params.Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, apiError{http.StatusBadRequest, name + " deve ser um número inteiro"}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,314 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/jsync"
+	"github.com/alanwgt/jsync/log"
+	"github.com/graphql-go/graphql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server serve os recursos sincronizados como JSON, lendo-os do banco a cada requisição. Apenas os imóveis ativos são
+// servidos.
+type Server struct {
+	j           jsync.JSync
+	cfg         config.Api
+	multiTenant bool
+	schemas     *graphqlSchemas
+}
+
+// apiError é um erro retornado ao cliente com o status fornecido.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e apiError) Error() string {
+	return e.message
+}
+
+type listMeta struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+type listResponse struct {
+	Data []map[string]any `json:"data"`
+	Meta listMeta         `json:"meta"`
+}
+
+type itemResponse struct {
+	Data map[string]any `json:"data"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// statusRecorder guarda o status da resposta para o log das requisições.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func New(j jsync.JSync, cfg config.Api) *Server {
+	return &Server{
+		j:           j,
+		cfg:         cfg,
+		multiTenant: len(j.Config().TenantMapping) > 0,
+		schemas:     &graphqlSchemas{schemas: map[string]graphql.Schema{}},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(rec, r)
+
+	log.Debug().
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int("status", rec.status).
+		Dur("duration", time.Since(start)).
+		Msg("requisição atendida")
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.CorsOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.cfg.CorsOrigin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match, X-Api-Key, X-Tenant")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	if strings.Trim(r.URL.Path, "/") == "graphql" {
+		s.serveGraphql(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, apiError{http.StatusMethodNotAllowed, "apenas requisições GET são aceitas"})
+		return
+	}
+
+	body, err := s.handle(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Authorization, X-Api-Key, X-Tenant")
+	if s.cfg.CacheMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", s.cfg.CacheMaxAge))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+// handle atende as rotas /{recurso} e /{recurso}/{id}.
+func (s *Server) handle(r *http.Request) (any, error) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	resource := parts[0]
+	if !isResource(resource) || len(parts) > 2 {
+		return nil, apiError{http.StatusNotFound, "rota não encontrada"}
+	}
+
+	j, _, err := s.tenant(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 2 {
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id <= 0 {
+			return nil, apiError{http.StatusNotFound, "item não encontrado"}
+		}
+
+		docs, _, err := j.ReadCatalog(resource, jsync.CatalogQuery{Id: id})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(docs) == 0 {
+			return nil, apiError{http.StatusNotFound, "item não encontrado"}
+		}
+
+		return itemResponse{docs[0]}, nil
+	}
+
+	q, page, perPage, err := listQuery(j, resource, r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	docs, total, err := j.ReadCatalog(resource, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if docs == nil {
+		docs = []map[string]any{}
+	}
+
+	return listResponse{docs, listMeta{page, perPage, total}}, nil
+}
+
+// tenant retorna o JSync no tenant da requisição. Com chaves configuradas, a chave define o tenant acessado; chaves sem
+// tenant, assim como a API sem chaves, utilizam o cabeçalho X-Tenant em ambientes multi tenancy.
+func (s *Server) tenant(r *http.Request) (jsync.JSync, string, error) {
+	identifier := r.Header.Get("X-Tenant")
+	if len(s.cfg.Keys) > 0 {
+		k, ok := s.apiKey(r)
+		if !ok {
+			return jsync.JSync{}, "", apiError{http.StatusUnauthorized, "chave de API ausente ou inválida"}
+		}
+
+		if k.Tenant != "" {
+			if identifier != "" && identifier != k.Tenant {
+				return jsync.JSync{}, "", apiError{http.StatusForbidden, "a chave de API não tem acesso ao tenant"}
+			}
+
+			identifier = k.Tenant
+		}
+	}
+
+	if s.multiTenant && identifier == "" {
+		return jsync.JSync{}, "", apiError{http.StatusBadRequest, "informe o tenant no cabeçalho X-Tenant"}
+	}
+
+	j, ok := s.j.InTenant(identifier)
+	if !ok {
+		return jsync.JSync{}, "", apiError{http.StatusNotFound, "tenant não encontrado"}
+	}
+
+	return j, identifier, nil
+}
+
+func (s *Server) apiKey(r *http.Request) (config.ApiKey, bool) {
+	key := r.Header.Get("X-Api-Key")
+	if key == "" {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	if key == "" {
+		return config.ApiKey{}, false
+	}
+
+	for _, k := range s.cfg.Keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return k, true
+		}
+	}
+
+	return config.ApiKey{}, false
+}
+
+func isResource(name string) bool {
+	for _, r := range config.Resources {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesETag indica se o cabeçalho If-None-Match contém a etag da resposta.
+func matchesETag(header string, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "erro interno"
+
+	var ae apiError
+	switch {
+	case errors.As(err, &ae):
+		status = ae.status
+		message = ae.message
+	case errors.Is(err, jsync.ErrCatalogQuery):
+		status = http.StatusBadRequest
+		message = err.Error()
+	default:
+		log.Error().Err(err).Msg("falha ao atender requisição da API")
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{message})
+}
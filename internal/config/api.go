@@ -0,0 +1,47 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	DefaultApiAddr    = ":8080"
+	DefaultApiPerPage = 20
+	MaxApiPerPage     = 100
+)
+
+// Api configura a API REST somente leitura dos dados sincronizados (jsync api).
+type Api struct {
+	Addr        string   `mapstructure:"addr"`          // endereço em que o servidor escuta, ex.: :8080
+	Keys        []ApiKey `mapstructure:"keys"`          // se vazio, a API não exige chave
+	CorsOrigin  string   `mapstructure:"cors_origin"`   // valor do cabeçalho Access-Control-Allow-Origin
+	CacheMaxAge int      `mapstructure:"cache_max_age"` // segundos em que as respostas podem ser reutilizadas sem revalidação
+}
+
+// ApiKey é uma chave de acesso à API, enviada no cabeçalho X-Api-Key ou como Authorization: Bearer. Uma chave sem
+// tenant acessa todos os tenants, escolhidos pelo cabeçalho X-Tenant.
+type ApiKey struct {
+	Key    string `mapstructure:"key"`
+	Tenant string `mapstructure:"tenant"`
+}
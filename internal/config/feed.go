@@ -0,0 +1,60 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+// Feed configura os dados do anunciante publicados nos feeds XML dos portais (jsync export feed).
+type Feed struct {
+	Provider      string            `mapstructure:"provider"` // nome da imobiliária
+	Email         string            `mapstructure:"email"`
+	ContactName   string            `mapstructure:"contact_name"`
+	Phone         string            `mapstructure:"phone"`
+	Website       string            `mapstructure:"website"`
+	PropertyTypes map[string]string `mapstructure:"property_types"` // tipo (ou tipo/subtipo) da Jetimob: tipo do VRSync
+}
+
+// Merge retorna uma cópia da configuração com os valores preenchidos em o sobrescritos.
+func (f Feed) Merge(o *Feed) Feed {
+	if o == nil {
+		return f
+	}
+
+	merged := f
+	for _, s := range []struct{ dst, src *string }{
+		{&merged.Provider, &o.Provider},
+		{&merged.Email, &o.Email},
+		{&merged.ContactName, &o.ContactName},
+		{&merged.Phone, &o.Phone},
+		{&merged.Website, &o.Website},
+	} {
+		if *s.src != "" {
+			*s.dst = *s.src
+		}
+	}
+
+	merged.PropertyTypes = mergeMap(f.PropertyTypes, o.PropertyTypes)
+
+	return merged
+}
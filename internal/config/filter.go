@@ -0,0 +1,57 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+// Filter restringe os itens sincronizados de um recurso. Field é a chave do campo na Jetimob (o último segmento da
+// tag `json`, como em `mappings`) ou o nome do campo no modelo (como `SaleValue`). Todas as condições configuradas
+// precisam ser satisfeitas:
+//
+//	filters:
+//	  properties:
+//	    - field: tipo
+//	      in: [Chácara, Fazenda, Sítio]
+//	    - field: contrato
+//	      contains: Compra
+//	    - field: valor_venda
+//	      min: 100000
+//	      max: 2000000
+type Filter struct {
+	Field    string `mapstructure:"field"`
+	Eq       any    `mapstructure:"eq"`       // igual ao valor (em campos com vários valores, algum deles)
+	In       []any  `mapstructure:"in"`       // igual a algum dos valores
+	Contains any    `mapstructure:"contains"` // contém o texto, ou o vetor contém o valor
+	Min      any    `mapstructure:"min"`      // maior ou igual ao número ou data
+	Max      any    `mapstructure:"max"`      // menor ou igual ao número ou data
+	Not      bool   `mapstructure:"not"`      // inverte o resultado do filtro
+}
+
+// Filters são os filtros de cada recurso, indexados pelo nome do recurso.
+type Filters map[string][]Filter
+
+// Merge retorna uma cópia dos filtros com os filtros dos recursos presentes em o substituídos.
+func (f Filters) Merge(o Filters) Filters {
+	return mergeMap(f, o)
+}
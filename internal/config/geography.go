@@ -0,0 +1,85 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	DefaultGeographyColumn = "location"
+	DefaultGeographyJitter = 300
+
+	GeographyExact       = "exact"       // coordenadas originais
+	GeographyApproximate = "approximate" // coordenadas deslocadas aleatoriamente até Jitter metros
+	GeographyHidden      = "hidden"      // coluna nula
+)
+
+// DefaultGeographyVisibility relaciona os valores de `geoposicionamento_visivel` da Jetimob aos modos de escrita da
+// coluna geography.
+var DefaultGeographyVisibility = map[string]string{
+	"0": GeographyHidden,
+	"1": GeographyApproximate,
+	"2": GeographyExact,
+}
+
+// Geography configura a escrita de uma coluna geography(Point,4326) a partir da latitude e longitude de imóveis e
+// condomínios. Em imóveis, o modo de escrita depende de `geoposicionamento_visivel`. Condomínios sempre utilizam as
+// coordenadas exatas.
+type Geography struct {
+	Enabled    bool              `mapstructure:"enabled"`
+	Column     string            `mapstructure:"column"`     // default: location
+	Resources  []string          `mapstructure:"resources"`  // properties e/ou condominiums, ambos se vazio
+	Jitter     float64           `mapstructure:"jitter"`     // raio máximo do deslocamento, em metros
+	Secret     string            `mapstructure:"secret"`     // chave do deslocamento, obrigatória no modo approximate
+	Visibility map[string]string `mapstructure:"visibility"` // sobrescreve DefaultGeographyVisibility
+}
+
+// Approximate indica se algum valor de `geoposicionamento_visivel` utiliza o modo approximate.
+func (g Geography) Approximate() bool {
+	for v := range DefaultGeographyVisibility {
+		if g.Mode(v) == GeographyApproximate {
+			return true
+		}
+	}
+
+	for _, m := range g.Visibility {
+		if m == GeographyApproximate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Mode retorna o modo de escrita para o valor de `geoposicionamento_visivel`. Valores desconhecidos não são escritos.
+func (g Geography) Mode(visibility string) string {
+	if m, ok := g.Visibility[visibility]; ok {
+		return m
+	}
+
+	if m, ok := DefaultGeographyVisibility[visibility]; ok {
+		return m
+	}
+
+	return GeographyHidden
+}
@@ -0,0 +1,173 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ColumnMapping é o mapeamento de um campo para uma coluna do banco de dados. No arquivo de configuração, o valor de
+// um campo pode ser apenas o nome da coluna, ou um objeto:
+//
+//	contrato:
+//	  column: contracts
+//	  transform:
+//	    - lowercase
+//	    - map: {compra: venda}
+//
+// Colunas que não vêm de um campo da Jetimob são declaradas com exatamente uma das chaves `value` (constante),
+// `template` (text/template avaliado sobre os campos do item) ou `sql` (expressão SQL). Nesse caso, a chave do
+// mapeamento é o nome da coluna:
+//
+//	synced_at:
+//	  sql: now()
+//	  type: TIMESTAMPTZ
+type ColumnMapping struct {
+	Column     string
+	Transforms []Transform
+	Value      any
+	Template   string
+	SQL        string
+	Type       string // tipo SQL da coluna, utilizado na geração do DDL
+	hasValue   bool
+}
+
+// Computed indica se a coluna é calculada (constante, template ou expressão SQL) ao invés de vir de um campo.
+func (cm ColumnMapping) Computed() bool {
+	return cm.hasValue || cm.Template != "" || cm.SQL != ""
+}
+
+// Transform é uma transformação aplicada ao valor de um campo antes da inserção.
+type Transform struct {
+	Name string
+	Args any
+}
+
+// ParseColumnMapping interpreta o valor do mapeamento do campo field.
+func ParseColumnMapping(field string, v any) (ColumnMapping, error) {
+	switch t := v.(type) {
+	case string:
+		if t == "" {
+			return ColumnMapping{}, errors.New(fmt.Sprintf(`o campo "%s" está mapeado para uma coluna vazia`, field))
+		}
+
+		return ColumnMapping{Column: t}, nil
+	case map[string]any:
+		return parseColumnObject(field, t)
+	}
+
+	return ColumnMapping{}, errors.New(fmt.Sprintf(`mapeamento inválido para o campo "%s": %v`, field, v))
+}
+
+func parseColumnObject(field string, obj map[string]any) (ColumnMapping, error) {
+	cm := ColumnMapping{Column: field}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := obj[k]
+		switch k {
+		case "column":
+			col, ok := v.(string)
+			if !ok || col == "" {
+				return cm, errors.New(fmt.Sprintf(`a coluna do campo "%s" precisa ser um texto não vazio`, field))
+			}
+
+			cm.Column = col
+		case "transform":
+			ts, err := parseTransforms(field, v)
+			if err != nil {
+				return cm, err
+			}
+
+			cm.Transforms = ts
+		case "value":
+			cm.Value, cm.hasValue = v, true
+		case "template", "sql", "type":
+			str, ok := v.(string)
+			if !ok || str == "" {
+				return cm, errors.New(fmt.Sprintf(`a chave "%s" do campo "%s" precisa ser um texto não vazio`, k, field))
+			}
+
+			switch k {
+			case "template":
+				cm.Template = str
+			case "sql":
+				cm.SQL = str
+			default:
+				cm.Type = str
+			}
+		default:
+			return cm, errors.New(fmt.Sprintf(`chave "%s" desconhecida no mapeamento do campo "%s"`, k, field))
+		}
+	}
+
+	sources := 0
+	for _, set := range []bool{cm.hasValue, cm.Template != "", cm.SQL != ""} {
+		if set {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return cm, errors.New(fmt.Sprintf(`o campo "%s" precisa declarar apenas uma das chaves "value", "template" ou "sql"`, field))
+	}
+
+	return cm, nil
+}
+
+func parseTransforms(field string, v any) ([]Transform, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`as transformações do campo "%s" precisam ser uma lista`, field))
+	}
+
+	ts := make([]Transform, len(list))
+	for i, item := range list {
+		switch t := item.(type) {
+		case string:
+			ts[i] = Transform{Name: t}
+		case map[string]any:
+			if len(t) != 1 {
+				return nil, errors.New(fmt.Sprintf(`a transformação %d do campo "%s" precisa ter exatamente uma chave`, i+1, field))
+			}
+
+			for name, args := range t {
+				ts[i] = Transform{Name: name, Args: args}
+			}
+		default:
+			return nil, errors.New(fmt.Sprintf(`transformação %d inválida no campo "%s": %v`, i+1, field, item))
+		}
+	}
+
+	return ts, nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	MediaStorageLocal = "local"
+	MediaStorageS3    = "s3"
+
+	DefaultMediaConcurrency = 8
+	DefaultMediaQuality     = 85
+
+	MediaFormatJpeg = "jpeg"
+	MediaFormatPng  = "png"
+	MediaFormatWebp = "webp"
+)
+
+// Media configura o espelhamento das imagens dos recursos. Quando habilitado, as imagens são baixadas para o storage
+// configurado e as URLs sincronizadas são reescritas para BaseUrl.
+type Media struct {
+	Enabled     bool           `mapstructure:"enabled"`
+	Storage     string         `mapstructure:"storage"`     // local ou s3
+	BaseUrl     string         `mapstructure:"base_url"`    // URL pública em que os arquivos do storage são servidos
+	Prefix      string         `mapstructure:"prefix"`      // prefixo adicionado às chaves dos arquivos
+	Concurrency int            `mapstructure:"concurrency"` // número de downloads simultâneos
+	Variants    []MediaVariant `mapstructure:"variants"`    // versões redimensionadas geradas para cada imagem
+	Local       MediaLocal     `mapstructure:"local"`
+	S3          MediaS3        `mapstructure:"s3"`
+}
+
+// MediaVariant é uma versão redimensionada das imagens. A imagem é reduzida, mantendo a proporção, para caber em
+// Width x Height (uma das dimensões pode ser omitida). Imagens menores não são ampliadas.
+type MediaVariant struct {
+	Name    string `mapstructure:"name"`
+	Width   int    `mapstructure:"width"`
+	Height  int    `mapstructure:"height"`
+	Format  string `mapstructure:"format"`  // jpeg (default), png ou webp (requer o cwebp)
+	Quality int    `mapstructure:"quality"` // qualidade de jpeg e webp, de 1 a 100
+}
+
+type MediaLocal struct {
+	Path string `mapstructure:"path"`
+}
+
+// MediaS3 configura um bucket compatível com S3 (AWS, MinIO, etc.). Se as credenciais não forem informadas, as
+// variáveis de ambiente AWS_ACCESS_KEY_ID e AWS_SECRET_ACCESS_KEY são utilizadas.
+type MediaS3 struct {
+	Endpoint  string `mapstructure:"endpoint"` // ex.: https://s3.us-east-1.amazonaws.com ou http://localhost:9000
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"` // utiliza endpoint/bucket/chave ao invés de bucket.endpoint/chave
+}
@@ -34,27 +34,114 @@ const (
 	DefaultCondominiumsTable = "condominiums"
 	DefaultBannersTable      = "banners"
 	DefaultBrokersTable      = "brokers"
+	DefaultTenantColumn      = "tenant_id"
+	DefaultBatchSize         = 1000
+
+	ResourceProperties   = "properties"
+	ResourceCondominiums = "condominiums"
+	ResourceBrokers      = "brokers"
+	ResourceBanners      = "banners"
 )
 
+// Resources lista todos os recursos sincronizados, na ordem em que são sincronizados.
+var Resources = []string{ResourceBanners, ResourceBrokers, ResourceCondominiums, ResourceProperties}
+
 type DB struct {
 	ConnectionString string `mapstructure:"connection_string"`
+	BatchSize        int    `mapstructure:"batch_size"` // número máximo de rows por comando
+	Copy             bool   `mapstructure:"copy"`       // utiliza COPY FROM STDIN numa tabela temporária
 }
 
 type TenantMapping struct {
-	Identifier    string `mapstructure:"identifier"`
-	WebserviceKey string `mapstructure:"webservice_key"`
+	Identifier    string    `mapstructure:"identifier"`
+	WebserviceKey string    `mapstructure:"webservice_key"`
+	Mappings      *Mappings `mapstructure:"mappings"`  // sobrescreve o mapeamento global apenas para o tenant
+	Resources     []string  `mapstructure:"resources"` // recursos sincronizados para o tenant, todos se vazio
+	Filters       Filters   `mapstructure:"filters"`   // substitui os filtros globais dos recursos informados
+	Feed          *Feed     `mapstructure:"feed"`      // sobrescreve os dados do anunciante nos feeds dos portais
+}
+
+// ResourceEnabled indica se o recurso deve ser sincronizado para o tenant.
+func (t TenantMapping) ResourceEnabled(resource string) bool {
+	if len(t.Resources) == 0 {
+		return true
+	}
+
+	for _, r := range t.Resources {
+		if r == resource {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Mappings struct {
-	CondominiumsTable *string           `mapstructure:"condominiums_table"`
-	PropertiesTable   *string           `mapstructure:"properties_table"`
-	BrokersTable      *string           `mapstructure:"brokers_table"`
-	BannersTable      *string           `mapstructure:"banners_table"`
-	Condominiums      map[string]any    `mapstructure:"condominiums"`
-	Properties        map[string]any    `mapstructure:"properties"`
-	Brokers           map[string]any    `mapstructure:"brokers"`
-	Banners           map[string]any    `mapstructure:"banners"`
-	Contracts         map[string]string `mapstructure:"contracts"`
+	CondominiumsTable     *string           `mapstructure:"condominiums_table"`
+	PropertiesTable       *string           `mapstructure:"properties_table"`
+	BrokersTable          *string           `mapstructure:"brokers_table"`
+	BannersTable          *string           `mapstructure:"banners_table"`
+	SeasonCalendarTable   *string           `mapstructure:"season_calendar_table"`   // tabela filha do calendário de temporada
+	PropertyMediaTable    *string           `mapstructure:"property_media_table"`    // tabela filha das mídias dos imóveis
+	CondominiumMediaTable *string           `mapstructure:"condominium_media_table"` // tabela filha das mídias dos condomínios
+	Condominiums          map[string]any    `mapstructure:"condominiums"`
+	Properties            map[string]any    `mapstructure:"properties"`
+	Brokers               map[string]any    `mapstructure:"brokers"`
+	Banners               map[string]any    `mapstructure:"banners"`
+	Contracts             map[string]string `mapstructure:"contracts"`
+	Public                *Public           `mapstructure:"public"` // modo público, aplicado aos imóveis
+}
+
+// Merge retorna uma cópia do mapeamento com as configurações de o sobrescritas. Os nomes das tabelas e o modo público
+// são substituídos e os mapeamentos de colunas e contratos são mesclados chave a chave.
+func (m Mappings) Merge(o *Mappings) Mappings {
+	if o == nil {
+		return m
+	}
+
+	merged := m
+	for _, t := range []struct{ dst, src **string }{
+		{&merged.CondominiumsTable, &o.CondominiumsTable},
+		{&merged.PropertiesTable, &o.PropertiesTable},
+		{&merged.BrokersTable, &o.BrokersTable},
+		{&merged.BannersTable, &o.BannersTable},
+		{&merged.SeasonCalendarTable, &o.SeasonCalendarTable},
+		{&merged.PropertyMediaTable, &o.PropertyMediaTable},
+		{&merged.CondominiumMediaTable, &o.CondominiumMediaTable},
+	} {
+		if *t.src != nil {
+			*t.dst = *t.src
+		}
+	}
+
+	merged.Condominiums = mergeMap(m.Condominiums, o.Condominiums)
+	merged.Properties = mergeMap(m.Properties, o.Properties)
+	merged.Brokers = mergeMap(m.Brokers, o.Brokers)
+	merged.Banners = mergeMap(m.Banners, o.Banners)
+	merged.Contracts = mergeMap(m.Contracts, o.Contracts)
+
+	if o.Public != nil {
+		merged.Public = o.Public
+	}
+
+	return merged
+}
+
+func mergeMap[V any](base, override map[string]V) map[string]V {
+	if override == nil {
+		return base
+	}
+
+	merged := make(map[string]V, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
 }
 
 type JetimobCfg struct {
@@ -64,6 +151,17 @@ type JetimobCfg struct {
 	TenantDiscriminatorColumn *string         `mapstructure:"tenant_column"`
 	TenantMapping             []TenantMapping `mapstructure:"tenant_mapping"`
 	Mappings                  Mappings        `mapstructure:"mappings"`
+	Filters                   Filters         `mapstructure:"filters"`
+	Media                     Media           `mapstructure:"media"`
+	Geography                 Geography       `mapstructure:"geography"`
+	Search                    Search          `mapstructure:"search"`
+	SearchIndex               SearchIndex     `mapstructure:"search_index"`
+	Sinks                     Sinks           `mapstructure:"sinks"`
+	Integrity                 Integrity       `mapstructure:"integrity"`
+	Feed                      Feed            `mapstructure:"feed"`
+	Api                       Api             `mapstructure:"api"`
+	Lint                      Lint            `mapstructure:"lint"`
+	TruncateAll               bool            `mapstructure:"truncate_all"` // remove os dados do tenant antes de sincronizar
 	CmdCfg                    CmdCfg
 }
 
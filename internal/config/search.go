@@ -0,0 +1,48 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	DefaultSearchColumn = "search_vector"
+	DefaultSearchConfig = "portuguese"
+)
+
+// DefaultSearchFields são os campos dos imóveis indexados, com os seus pesos, se nenhum campo for configurado.
+var DefaultSearchFields = map[string]string{
+	"titulo_anuncio":     "A",
+	"endereco_bairro":    "B",
+	"tags":               "B",
+	"imovel_comodidades": "C",
+	"descricao_anuncio":  "D",
+}
+
+// Search configura a manutenção de uma coluna tsvector nos imóveis, utilizada para busca textual.
+type Search struct {
+	Enabled bool              `mapstructure:"enabled"`
+	Column  string            `mapstructure:"column"` // default: search_vector
+	Config  string            `mapstructure:"config"` // configuração de busca textual do postgres, default: portuguese
+	Fields  map[string]string `mapstructure:"fields"` // campo: peso (A, B, C ou D)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	SearchEngineMeilisearch = "meilisearch"
+	SearchEngineOpensearch  = "opensearch"
+
+	DefaultSearchIndexBatchSize = 1000
+)
+
+// SearchIndex configura a indexação dos imóveis e condomínios sincronizados num motor de busca externo. Os documentos
+// são as rows sincronizadas, com os nomes de colunas do mapeamento.
+type SearchIndex struct {
+	Enabled   bool                           `mapstructure:"enabled"`
+	Engine    string                         `mapstructure:"engine"`     // meilisearch ou opensearch
+	Url       string                         `mapstructure:"url"`        // ex.: http://localhost:7700
+	ApiKey    string                         `mapstructure:"api_key"`    // chave do meilisearch
+	Username  string                         `mapstructure:"username"`   // usuário do opensearch (basic auth)
+	Password  string                         `mapstructure:"password"`   // senha do opensearch (basic auth)
+	BatchSize int                            `mapstructure:"batch_size"` // número máximo de documentos por requisição
+	Resources map[string]SearchIndexResource `mapstructure:"resources"`  // properties e/ou condominiums
+}
+
+// SearchIndexResource configura o índice de um recurso. Em ambientes multi tenancy, o identificador do tenant é
+// adicionado ao nome do índice (ex.: properties_1).
+type SearchIndexResource struct {
+	Index  string   `mapstructure:"index"`  // nome do índice, padrão é o nome da tabela
+	Fields []string `mapstructure:"fields"` // colunas incluídas nos documentos, todas as colunas mapeadas se vazio
+}
@@ -0,0 +1,47 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package config
+
+const (
+	SinkPostgres = "postgres"
+	SinkFile     = "file"
+	SinkWebhook  = "webhook"
+
+	DefaultSinkBatchSize = 500
+)
+
+// Sinks relaciona cada recurso com os destinos em que os dados sincronizados são escritos. Recursos sem sinks
+// configurados são escritos apenas no banco de dados (postgres).
+type Sinks map[string][]Sink
+
+// Sink configura um destino dos dados sincronizados. Os sinks de um recurso são confirmados na ordem configurada.
+type Sink struct {
+	Type      string            `mapstructure:"type"`       // postgres, file ou webhook
+	Path      string            `mapstructure:"path"`       // diretório dos arquivos do sink file
+	Url       string            `mapstructure:"url"`        // endpoint do sink webhook
+	Headers   map[string]string `mapstructure:"headers"`    // cabeçalhos enviados nas requisições do webhook
+	BatchSize int               `mapstructure:"batch_size"` // número máximo de rows por requisição do webhook
+}
@@ -28,11 +28,16 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/alanwgt/jsync/log"
 	_ "github.com/lib/pq"
 )
 
+// ErrNotConfigured é retornado pelas operações no banco quando a connection string não foi configurada, o que é permitido
+// para comandos que não utilizam o banco (ex.: jsync export).
+var ErrNotConfigured = errors.New("o banco de dados não está configurado, informe db.connection_string")
+
 type Db struct {
 	driver           string
 	connectionString string
@@ -47,6 +52,10 @@ func New(connectionString string) *Db {
 }
 
 func (db *Db) Connect() (err error) {
+	if db.connectionString == "" {
+		return nil
+	}
+
 	db.connection, err = sql.Open(db.driver, db.connectionString)
 	if err != nil {
 		return
@@ -55,11 +64,24 @@ func (db *Db) Connect() (err error) {
 	return nil
 }
 
+// Configured indica se a connection string foi configurada.
+func (db Db) Configured() bool {
+	return db.connection != nil
+}
+
 func (db Db) Exec(query string, args ...any) (sql.Result, error) {
+	if !db.Configured() {
+		return nil, ErrNotConfigured
+	}
+
 	return db.connection.Exec(query, args...)
 }
 
 func (db Db) Query(query string, args ...any) (*sql.Rows, error) {
+	if !db.Configured() {
+		return nil, ErrNotConfigured
+	}
+
 	return db.connection.Query(query, args...)
 }
 
@@ -72,6 +94,10 @@ func (db Db) Truncate(table string) (sql.Result, error) {
 }
 
 func (db Db) ExecInTx(f func(*sql.Tx) error) error {
+	if !db.Configured() {
+		return ErrNotConfigured
+	}
+
 	tx, err := db.Connection().BeginTx(context.Background(), nil)
 	if err != nil {
 		return err
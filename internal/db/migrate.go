@@ -0,0 +1,315 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/log"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MigrationsTable é a mesma tabela utilizada pelo golang-migrate, permitindo que bancos migrados anteriormente pela
+// ferramenta continuem a partir da última versão aplicada.
+const MigrationsTable = "schema_migrations"
+
+// migrationsLockId identifica o advisory lock utilizado para impedir migrações concorrentes. Como a versão é lida antes
+// do lock, cada migração confere a versão novamente após obtê-lo.
+const migrationsLockId = 7_221_853_104
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+type Migrator struct {
+	db          *Db
+	migrations  []Migration
+	identifiers *regexp.Regexp
+	renames     map[string]string
+}
+
+// NewMigrator carrega as migrações de src. As chaves de renames são identificadores (tabelas ou colunas) utilizados
+// nos arquivos SQL que devem ser substituídos pelos valores correspondentes antes da execução.
+func NewMigrator(db *Db, src fs.FS, renames map[string]string) (*Migrator, error) {
+	ms, err := loadMigrations(src)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		db:         db,
+		migrations: ms,
+		renames:    make(map[string]string),
+	}
+
+	var names []string
+	for from, to := range renames {
+		if from == to || to == "" {
+			continue
+		}
+
+		m.renames[from] = to
+		names = append(names, regexp.QuoteMeta(from))
+	}
+
+	if len(names) > 0 {
+		sort.Strings(names)
+		m.identifiers = regexp.MustCompile(fmt.Sprintf(`\b(%s)\b`, strings.Join(names, "|")))
+	}
+
+	return m, nil
+}
+
+func loadMigrations(src fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(src, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, e := range entries {
+		parts := migrationFileRegex.FindStringSubmatch(e.Name())
+		if e.IsDir() || parts == nil {
+			continue
+		}
+
+		v, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		bs, err := fs.ReadFile(src, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(v)]
+		if !ok {
+			m = &Migration{Version: uint(v), Name: parts[2]}
+			byVersion[uint(v)] = m
+		}
+
+		if parts[3] == "up" {
+			m.up = string(bs)
+		} else {
+			m.down = string(bs)
+		}
+	}
+
+	ms := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		ms = append(ms, *m)
+	}
+
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+
+	return ms, nil
+}
+
+func (m Migrator) rename(query string) string {
+	if m.identifiers == nil {
+		return query
+	}
+
+	return m.identifiers.ReplaceAllStringFunc(query, func(s string) string {
+		return m.renames[s]
+	})
+}
+
+func (m Migrator) ensureMigrationsTable() error {
+	_, err := m.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`, MigrationsTable))
+	return err
+}
+
+// Version retorna a última versão aplicada. Se nenhuma migração foi aplicada, a versão é 0.
+func (m Migrator) Version() (version uint, dirty bool, err error) {
+	if err = m.ensureMigrationsTable(); err != nil {
+		return
+	}
+
+	err = m.db.Connection().
+		QueryRow(fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", MigrationsTable)).
+		Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return
+}
+
+func (m Migrator) Status() ([]MigrationStatus, error) {
+	v, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]MigrationStatus, len(m.migrations))
+	for i, mig := range m.migrations {
+		ss[i] = MigrationStatus{
+			Migration: mig,
+			Applied:   mig.Version <= v,
+		}
+	}
+
+	return ss, nil
+}
+
+// Up aplica até n migrações pendentes. Se n <= 0, todas as migrações pendentes são aplicadas.
+func (m Migrator) Up(n int) error {
+	v, err := m.checkedVersion()
+	if err != nil {
+		return err
+	}
+
+	applied := 0
+	for _, mig := range m.migrations {
+		if mig.Version <= v {
+			continue
+		}
+
+		if n > 0 && applied == n {
+			break
+		}
+
+		log.Info().Uint("version", mig.Version).Str("name", mig.Name).Msg("aplicando migração")
+		if err = m.apply(mig.up, v, &mig.Version); err != nil {
+			return fmt.Errorf("falha ao aplicar migração %d_%s: %w", mig.Version, mig.Name, err)
+		}
+
+		v = mig.Version
+		applied++
+	}
+
+	if applied == 0 {
+		log.Info().Uint("version", v).Msg("nenhuma migração pendente")
+	}
+
+	return nil
+}
+
+// Down reverte até n migrações aplicadas. Se n <= 0, todas as migrações são revertidas.
+func (m Migrator) Down(n int) error {
+	v, err := m.checkedVersion()
+	if err != nil {
+		return err
+	}
+
+	reverted := 0
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version > v {
+			continue
+		}
+
+		if n > 0 && reverted == n {
+			break
+		}
+
+		var prev *uint
+		if i > 0 {
+			prev = &m.migrations[i-1].Version
+		}
+
+		log.Info().Uint("version", mig.Version).Str("name", mig.Name).Msg("revertendo migração")
+		if err = m.apply(mig.down, mig.Version, prev); err != nil {
+			return fmt.Errorf("falha ao reverter migração %d_%s: %w", mig.Version, mig.Name, err)
+		}
+
+		reverted++
+	}
+
+	if reverted == 0 {
+		log.Info().Msg("nenhuma migração para reverter")
+	}
+
+	return nil
+}
+
+func (m Migrator) checkedVersion() (uint, error) {
+	v, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("o banco está marcado como sujo na versão %d, corrija manualmente a tabela %s antes de continuar", v, MigrationsTable)
+	}
+
+	return v, nil
+}
+
+// apply executa a query e registra a versão resultante na mesma transação. from é a versão esperada antes da
+// migração: se outro migrador a alterou enquanto o lock era aguardado, nada é executado. Uma versão nil significa que
+// não há mais nenhuma migração aplicada.
+func (m Migrator) apply(query string, from uint, version *uint) error {
+	return m.db.ExecInTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationsLockId); err != nil {
+			return err
+		}
+
+		var current uint
+		err := tx.QueryRow(fmt.Sprintf("SELECT version FROM %s LIMIT 1", MigrationsTable)).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if current != from {
+			return fmt.Errorf("a versão do banco mudou de %d para %d, outra migração foi executada ao mesmo tempo", from, current)
+		}
+
+		if _, err := tx.Exec(m.rename(query)); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s", MigrationsTable)); err != nil {
+			return err
+		}
+
+		if version == nil {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES ($1, false)", MigrationsTable), *version)
+		return err
+	})
+}
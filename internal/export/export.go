@@ -0,0 +1,101 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCsv     = "csv"
+	FormatNdjson  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+var Formats = []string{FormatCsv, FormatNdjson, FormatParquet}
+
+// Type é o tipo de uma coluna exportada.
+type Type int
+
+const (
+	TypeText Type = iota
+	TypeInt
+	TypeFloat
+	TypeBool
+	TypeTime
+	TypeTextList
+	TypeJson
+)
+
+// Column descreve uma coluna exportada. Os valores das rows devem ser tipos básicos (nil, string, int64, float64,
+// bool, time.Time ou []string); valores de outros tipos são serializados como JSON.
+type Column struct {
+	Name string
+	Type Type
+}
+
+var writers = map[string]func(io.Writer, []Column, [][]any) error{
+	FormatCsv:     writeCsv,
+	FormatNdjson:  writeNdjson,
+	FormatParquet: writeParquet,
+}
+
+// Write escreve as rows no formato informado. Os valores de cada row seguem a ordem das colunas.
+func Write(w io.Writer, format string, cols []Column, rows [][]any) error {
+	write, ok := writers[format]
+	if !ok {
+		return errors.New(fmt.Sprintf(`formato de exportação "%s" desconhecido, utilize %s`, format, strings.Join(Formats, ", ")))
+	}
+
+	return write(w, cols, rows)
+}
+
+// text converte o valor para a sua representação textual, utilizada no CSV e nas colunas de texto do Parquet.
+func text(v any) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339), nil
+	}
+
+	bs, err := json.Marshal(v)
+	return string(bs), err
+}
@@ -0,0 +1,112 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package export
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+	"math"
+	"time"
+)
+
+// parquetTypes são as definições das colunas no schema do Parquet. Vetores de texto e valores json são gravados como
+// texto JSON.
+var parquetTypes = map[Type]string{
+	TypeText:     "type=BYTE_ARRAY, convertedtype=UTF8",
+	TypeInt:      "type=INT64",
+	TypeFloat:    "type=DOUBLE",
+	TypeBool:     "type=BOOLEAN",
+	TypeTime:     "type=INT64, convertedtype=TIMESTAMP_MILLIS",
+	TypeTextList: "type=BYTE_ARRAY, convertedtype=UTF8",
+	TypeJson:     "type=BYTE_ARRAY, convertedtype=UTF8",
+}
+
+func writeParquet(w io.Writer, cols []Column, rows [][]any) error {
+	md := make([]string, len(cols))
+	for i, c := range cols {
+		md[i] = fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", c.Name, parquetTypes[c.Type])
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, 1)
+	if err != nil {
+		return err
+	}
+
+	record := make([]any, len(cols))
+	for _, row := range rows {
+		for i, v := range row {
+			if record[i], err = parquetValue(cols[i].Type, v); err != nil {
+				return fmt.Errorf(`coluna "%s": %w`, cols[i].Name, err)
+			}
+		}
+
+		if err = pw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return pw.WriteStop()
+}
+
+// parquetValue converte o valor para o tipo físico da coluna.
+func parquetValue(t Type, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t {
+	case TypeInt:
+		switch n := v.(type) {
+		case int64:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int64(n), nil
+			}
+		}
+	case TypeFloat:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case int64:
+			return float64(n), nil
+		}
+	case TypeBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case TypeTime:
+		if tm, ok := v.(time.Time); ok {
+			return tm.UnixMilli(), nil
+		}
+	default:
+		return text(v)
+	}
+
+	return nil, errors.New(fmt.Sprintf("valor %v (%T) incompatível com o tipo da coluna", v, v))
+}
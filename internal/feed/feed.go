@@ -0,0 +1,153 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/log"
+	"github.com/alanwgt/jsync/schemas"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatVrsync = "vrsync"
+	FormatOlx    = "olx"
+)
+
+var Formats = []string{FormatVrsync, FormatOlx}
+
+// ErrValidatorNotFound é retornado por Validate quando o xmllint não está instalado.
+var ErrValidatorNotFound = errors.New("xmllint não encontrado no PATH, o feed não foi validado")
+
+var builders = map[string]func([]listing, Options) (any, error){
+	FormatVrsync: buildVrsync,
+	FormatOlx:    buildOlx,
+}
+
+// Options são os dados do anunciante e as opções de publicação do feed.
+type Options struct {
+	config.Feed
+	PublishDate time.Time
+	// Coordinates retorna as coordenadas publicáveis do imóvel. Se nil, as coordenadas não são publicadas.
+	Coordinates func(p model.Property) (lat, lng float64, ok bool)
+	// PropertyURL é o template da URL da página do imóvel, com os marcadores {codigo} e/ou {id}. Utilizado no sitemap e
+	// nos dados estruturados.
+	PropertyURL string
+	// SitemapURL é a URL base dos arquivos do sitemap, utilizada no índice quando o sitemap é dividido. Se vazia, a
+	// raiz do domínio de PropertyURL é utilizada.
+	SitemapURL string
+}
+
+// Build gera o feed XML dos imóveis no formato informado. Apenas os dados marcados como visíveis na Jetimob são
+// publicados.
+func Build(format string, ps []model.Property, opts Options) ([]byte, error) {
+	build, ok := builders[format]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`formato de feed "%s" desconhecido, utilize %s`, format, strings.Join(Formats, " ou ")))
+	}
+
+	if opts.PublishDate.IsZero() {
+		opts.PublishDate = time.Now()
+	}
+
+	ls := make([]listing, len(ps))
+	for i, p := range ps {
+		ls[i] = newListing(p, opts)
+	}
+
+	f, err := build(ls, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeXml(f)
+}
+
+// Validate valida o feed contra o XSD do formato, distribuído com o jsync, utilizando o xmllint. Se o xmllint não
+// estiver instalado, ErrValidatorNotFound é retornado.
+func Validate(format string, data []byte) error {
+	xsd, err := schemas.FS.ReadFile(format + ".xsd")
+	if err != nil {
+		return errors.New(fmt.Sprintf(`schema do formato "%s" não encontrado`, format))
+	}
+
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		return ErrValidatorNotFound
+	}
+
+	dir, err := os.MkdirTemp("", "jsync-feed-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	schemaPath := filepath.Join(dir, format+".xsd")
+	feedPath := filepath.Join(dir, "feed.xml")
+	if err = os.WriteFile(schemaPath, xsd, 0644); err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(feedPath, data, 0644); err != nil {
+		return err
+	}
+
+	log.Debug().Str("format", format).Msg("validando feed com o xmllint")
+	out, err := exec.Command(xmllint, "--noout", "--schema", schemaPath, feedPath).CombinedOutput()
+	if err != nil {
+		msg := strings.ReplaceAll(string(out), feedPath+":", "linha ")
+		msg = strings.TrimSpace(strings.ReplaceAll(msg, feedPath+" fails to validate", ""))
+		return errors.New(fmt.Sprintf("o feed não é válido segundo o schema %s.xsd:\n%s", format, msg))
+	}
+
+	return nil
+}
+
+func encodeXml(v any) ([]byte, error) {
+	buf := bytes.NewBufferString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,206 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"encoding/json"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+	"time"
+)
+
+const schemaOrgContext = "https://schema.org"
+
+// schemaOrgTypes relaciona o slug dos tipos da Jetimob com os tipos do schema.org do imóvel anunciado. Os demais tipos
+// são publicados como Accommodation.
+var schemaOrgTypes = map[string]string{
+	"apartamento":        "Apartment",
+	"cobertura":          "Apartment",
+	"flat":               "Apartment",
+	"garden":             "Apartment",
+	"kitnet":             "Apartment",
+	"kitinete":           "Apartment",
+	"loft":               "Apartment",
+	"studio":             "Apartment",
+	"casa":               "House",
+	"casa-de-condominio": "House",
+	"casa-em-condominio": "House",
+	"sobrado":            "House",
+	"terreno":            "Place",
+	"lote":               "Place",
+}
+
+type ldListing struct {
+	Context      string       `json:"@context"`
+	Type         string       `json:"@type"`
+	Url          string       `json:"url"`
+	Identifier   string       `json:"identifier"`
+	Name         string       `json:"name"`
+	Description  string       `json:"description,omitempty"`
+	Image        []string     `json:"image,omitempty"`
+	DatePosted   string       `json:"datePosted,omitempty"`
+	DateModified string       `json:"dateModified,omitempty"`
+	About        ldPlace      `json:"about"`
+	Offers       []ldOffer    `json:"offers,omitempty"`
+	Provider     *ldOrganizer `json:"provider,omitempty"`
+}
+
+type ldPlace struct {
+	Type              string      `json:"@type"`
+	Address           ldAddress   `json:"address"`
+	Geo               *ldGeo      `json:"geo,omitempty"`
+	NumberOfBedrooms  int         `json:"numberOfBedrooms,omitempty"`
+	NumberOfBathrooms int         `json:"numberOfBathroomsTotal,omitempty"`
+	FloorSize         *ldQuantity `json:"floorSize,omitempty"`
+	AmenityFeature    []ldFeature `json:"amenityFeature,omitempty"`
+}
+
+type ldAddress struct {
+	Type            string `json:"@type"`
+	StreetAddress   string `json:"streetAddress,omitempty"`
+	AddressLocality string `json:"addressLocality,omitempty"`
+	AddressRegion   string `json:"addressRegion,omitempty"`
+	PostalCode      string `json:"postalCode,omitempty"`
+	AddressCountry  string `json:"addressCountry"`
+}
+
+type ldGeo struct {
+	Type      string  `json:"@type"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ldQuantity struct {
+	Type     string  `json:"@type"`
+	Value    float64 `json:"value"`
+	UnitCode string  `json:"unitCode"`
+}
+
+type ldOffer struct {
+	Type             string  `json:"@type"`
+	BusinessFunction string  `json:"businessFunction"`
+	Price            float64 `json:"price"`
+	PriceCurrency    string  `json:"priceCurrency"`
+}
+
+type ldOrganizer struct {
+	Type      string `json:"@type"`
+	Name      string `json:"name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Telephone string `json:"telephone,omitempty"`
+	Url       string `json:"url,omitempty"`
+}
+
+type ldFeature struct {
+	Type  string `json:"@type"`
+	Name  string `json:"name"`
+	Value bool   `json:"value"`
+}
+
+// StructuredData gera os dados estruturados (JSON-LD) do schema.org RealEstateListing da página do imóvel. Assim como
+// nos feeds, apenas os preços e as partes do endereço visíveis na Jetimob são publicados.
+func StructuredData(p model.Property, opts Options) ([]byte, error) {
+	if err := validatePropertyURL(opts.PropertyURL); err != nil {
+		return nil, err
+	}
+
+	l := newListing(p, opts)
+	ld := ldListing{
+		Context:     schemaOrgContext,
+		Type:        "RealEstateListing",
+		Url:         PropertyURL(opts.PropertyURL, p),
+		Identifier:  l.code,
+		Name:        l.title,
+		Description: l.description,
+		About: ldPlace{
+			Type: "Accommodation",
+			Address: ldAddress{
+				Type:            "PostalAddress",
+				StreetAddress:   l.street,
+				AddressLocality: l.city,
+				AddressRegion:   l.stateAbbr,
+				PostalCode:      l.zipcode,
+				AddressCountry:  "BR",
+			},
+		},
+	}
+
+	if t, ok := schemaOrgTypes[transform.Slug(l.propertyType)]; ok {
+		ld.About.Type = t
+	}
+
+	if l.number != "" {
+		ld.About.Address.StreetAddress += ", " + l.number
+	}
+
+	if ld.About.Address.AddressRegion == "" {
+		ld.About.Address.AddressRegion = l.state
+	}
+
+	if ld.About.Type != "Place" {
+		ld.About.NumberOfBedrooms = l.bedrooms
+		ld.About.NumberOfBathrooms = l.bathrooms
+		if a := l.livingArea; a != nil {
+			ld.About.FloorSize = &ldQuantity{Type: "QuantitativeValue", Value: *a, UnitCode: "MTK"}
+		} else if a = l.totalArea; a != nil {
+			ld.About.FloorSize = &ldQuantity{Type: "QuantitativeValue", Value: *a, UnitCode: "MTK"}
+		}
+
+		for _, f := range l.features {
+			ld.About.AmenityFeature = append(ld.About.AmenityFeature, ldFeature{Type: "LocationFeatureSpecification", Name: f, Value: true})
+		}
+	}
+
+	if l.lat != nil && l.lng != nil {
+		ld.About.Geo = &ldGeo{Type: "GeoCoordinates", Latitude: *l.lat, Longitude: *l.lng}
+	}
+
+	for _, img := range l.images {
+		ld.Image = append(ld.Image, img.url)
+	}
+
+	if !p.CreatedAt.IsZero() {
+		ld.DatePosted = p.CreatedAt.Format(time.RFC3339)
+	}
+
+	if !l.updatedAt.IsZero() {
+		ld.DateModified = l.updatedAt.Format(time.RFC3339)
+	}
+
+	if l.salePrice != nil {
+		ld.Offers = append(ld.Offers, ldOffer{"Offer", "http://purl.org/goodrelations/v1#Sell", *l.salePrice, currency})
+	}
+
+	if l.rentPrice != nil {
+		ld.Offers = append(ld.Offers, ldOffer{"Offer", "http://purl.org/goodrelations/v1#LeaseOut", *l.rentPrice, currency})
+	}
+
+	if opts.Provider != "" {
+		ld.Provider = &ldOrganizer{Type: "RealEstateAgent", Name: opts.Provider, Email: opts.Email, Telephone: opts.Phone, Url: opts.Website}
+	}
+
+	return json.MarshalIndent(ld, "", "  ")
+}
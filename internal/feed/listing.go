@@ -0,0 +1,241 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+	"gopkg.in/guregu/null.v4"
+	"strings"
+	"time"
+)
+
+const squareMetersPerHectare = 10_000
+
+// brazilianStates relaciona o slug do nome dos estados com a sua sigla.
+var brazilianStates = map[string]string{
+	"acre": "AC", "alagoas": "AL", "amapa": "AP", "amazonas": "AM", "bahia": "BA", "ceara": "CE",
+	"distrito-federal": "DF", "espirito-santo": "ES", "goias": "GO", "maranhao": "MA", "mato-grosso": "MT",
+	"mato-grosso-do-sul": "MS", "minas-gerais": "MG", "para": "PA", "paraiba": "PB", "parana": "PR",
+	"pernambuco": "PE", "piaui": "PI", "rio-de-janeiro": "RJ", "rio-grande-do-norte": "RN",
+	"rio-grande-do-sul": "RS", "rondonia": "RO", "roraima": "RR", "santa-catarina": "SC", "sao-paulo": "SP",
+	"sergipe": "SE", "tocantins": "TO",
+}
+
+type listingImage struct {
+	url     string
+	caption string
+}
+
+// listing contém apenas os dados publicáveis de um imóvel: os preços e as partes do endereço marcadas como não
+// visíveis na Jetimob nunca chegam aos formatos.
+type listing struct {
+	id           int
+	code         string
+	title        string
+	description  string
+	propertyType string
+	subtype      string
+	sale         bool
+	rent         bool
+	season       bool
+	featured     bool
+	salePrice    *float64
+	rentPrice    *float64
+	seasonPrice  *float64
+	condoFee     *float64
+	yearlyTax    *float64
+	livingArea   *float64
+	lotArea      *float64
+	totalArea    *float64
+	bedrooms     int
+	bathrooms    int
+	suites       int
+	garages      int
+	features     []string
+	display      string // parte do endereço exibida: All, Street ou Neighborhood
+	state        string
+	stateAbbr    string
+	city         string
+	neighborhood string
+	street       string
+	number       string
+	complement   string
+	zipcode      string
+	floor        *int64
+	lat          *float64
+	lng          *float64
+	images       []listingImage
+	videos       []string
+	updatedAt    time.Time
+}
+
+func newListing(p model.Property, opts Options) listing {
+	l := listing{
+		id:           p.Id,
+		code:         Code(p),
+		title:        p.AdTitle.String,
+		description:  p.AdDescription.String,
+		propertyType: p.Type,
+		subtype:      p.Subtype,
+		featured:     bool(p.Featured),
+		bedrooms:     p.Bedrooms,
+		bathrooms:    p.Bathrooms,
+		suites:       p.Suites,
+		garages:      p.Garages,
+		updatedAt:    p.UpdatedAt.Time,
+	}
+
+	for _, c := range p.Contracts {
+		switch transform.Slug(c) {
+		case "compra", "venda":
+			l.sale = true
+		case "locacao", "aluguel":
+			l.rent = true
+		case "temporada":
+			l.season = true
+		}
+	}
+
+	visible := p.Visibility()
+	if l.sale && visible.SaleValue && p.SaleValue.Valid {
+		l.salePrice = &p.SaleValue.Float64
+	}
+
+	if l.rent && visible.RentalValue && p.RentalValue.Valid {
+		l.rentPrice = &p.RentalValue.Float64
+	}
+
+	if l.season && visible.SeasonalValue && p.SeasonalValue.Valid {
+		l.seasonPrice = &p.SeasonalValue.Float64
+	}
+
+	if visible.CondominiumValue && p.CondominiumValue.Valid && p.CondominiumValue.Float64 > 0 {
+		l.condoFee = &p.CondominiumValue.Float64
+	}
+
+	if visible.IptuValue && p.IptuValue.Valid && p.IptuValue.Float64 > 0 {
+		tax := p.IptuValue.Float64
+		if strings.HasPrefix(transform.Slug(p.IptuFrequency.String), "mensal") {
+			tax *= 12
+		}
+
+		l.yearlyTax = &tax
+	}
+
+	hectares := strings.HasPrefix(transform.Slug(p.MeasurementType), "h")
+	area := func(vs ...null.Float) *float64 {
+		for _, v := range vs {
+			if a := v.ValueOrZero(); a > 0 {
+				if hectares {
+					a *= squareMetersPerHectare
+				}
+
+				return &a
+			}
+		}
+
+		return nil
+	}
+
+	l.livingArea = area(p.PrivateArea, p.UsefulArea)
+	l.totalArea = area(p.TotalArea)
+	l.lotArea = area(p.TerrainArea)
+
+	l.features = append(l.features, p.PropertyInfrastructures...)
+	l.features = append(l.features, p.CondominiumInfrastructures...)
+
+	l.setAddress(p)
+
+	if opts.Coordinates != nil {
+		if lat, lng, ok := opts.Coordinates(p); ok {
+			l.lat, l.lng = &lat, &lng
+		}
+	}
+
+	for _, m := range p.Images {
+		if m.Url != "" {
+			l.images = append(l.images, listingImage{url: m.Url, caption: m.Title.String})
+		}
+	}
+
+	for _, v := range p.Videos {
+		if v.Url != "" {
+			l.videos = append(l.videos, v.Url)
+		}
+	}
+
+	if l.title == "" {
+		l.title = strings.TrimSpace(p.Type + " " + l.neighborhood)
+	}
+
+	if l.title == "" {
+		l.title = l.code
+	}
+
+	return l
+}
+
+// setAddress preenche apenas as partes visíveis do endereço.
+func (l *listing) setAddress(p model.Property) {
+	visible := p.Visibility()
+	if visible.State {
+		l.state = p.AddressState
+		if len(p.AddressState) == 2 {
+			l.stateAbbr = strings.ToUpper(p.AddressState)
+		} else {
+			l.stateAbbr = brazilianStates[transform.Slug(p.AddressState)]
+		}
+	}
+
+	if visible.City {
+		l.city = p.AddressCity
+	}
+
+	if visible.Neighborhood {
+		l.neighborhood = p.AddressNeighborhood
+	}
+
+	l.display = "Neighborhood"
+	if visible.Street {
+		l.street = p.AddressStreet
+		l.zipcode = p.AddressZipcode.String
+		l.display = "Street"
+	}
+
+	if visible.Number && l.street != "" {
+		l.number = p.AddressNumber
+		l.display = "All"
+	}
+
+	if visible.Reference && l.street != "" {
+		l.complement = p.AddressReference.String
+	}
+
+	if visible.Floor && p.AddressFloor.Valid {
+		l.floor = &p.AddressFloor.Int64
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"encoding/xml"
+	"github.com/alanwgt/jsync/log"
+)
+
+type olxFeed struct {
+	XMLName xml.Name    `xml:"Carga"`
+	Xsi     string      `xml:"xmlns:xsi,attr"`
+	Imoveis []olxImovel `xml:"Imoveis>Imovel"`
+}
+
+type olxImovel struct {
+	CodigoImovel          string              `xml:"CodigoImovel"`
+	TipoImovel            string              `xml:"TipoImovel"`
+	SubTipoImovel         string              `xml:"SubTipoImovel,omitempty"`
+	TituloImovel          string              `xml:"TituloImovel"`
+	Observacao            string              `xml:"Observacao,omitempty"`
+	UF                    string              `xml:"UF,omitempty"`
+	Cidade                string              `xml:"Cidade,omitempty"`
+	Bairro                string              `xml:"Bairro,omitempty"`
+	Endereco              string              `xml:"Endereco,omitempty"`
+	Numero                string              `xml:"Numero,omitempty"`
+	Complemento           string              `xml:"Complemento,omitempty"`
+	CEP                   string              `xml:"CEP,omitempty"`
+	PrecoVenda            string              `xml:"PrecoVenda,omitempty"`
+	PrecoLocacao          string              `xml:"PrecoLocacao,omitempty"`
+	PrecoLocacaoTemporada string              `xml:"PrecoLocacaoTemporada,omitempty"`
+	PrecoCondominio       string              `xml:"PrecoCondominio,omitempty"`
+	ValorIPTU             string              `xml:"ValorIPTU,omitempty"`
+	AreaUtil              string              `xml:"AreaUtil,omitempty"`
+	AreaTotal             string              `xml:"AreaTotal,omitempty"`
+	QtdDormitorios        int                 `xml:"QtdDormitorios"`
+	QtdSuites             int                 `xml:"QtdSuites"`
+	QtdBanheiros          int                 `xml:"QtdBanheiros"`
+	QtdVagas              int                 `xml:"QtdVagas"`
+	Andar                 *int64              `xml:"Andar,omitempty"`
+	Latitude              *float64            `xml:"Latitude,omitempty"`
+	Longitude             *float64            `xml:"Longitude,omitempty"`
+	Destaque              bool                `xml:"Destaque"`
+	Caracteristicas       *olxCaracteristicas `xml:"Caracteristicas,omitempty"`
+	Fotos                 *olxFotos           `xml:"Fotos,omitempty"`
+	Videos                *olxVideos          `xml:"Videos,omitempty"`
+}
+
+// os wrappers são ponteiros para que os elementos sejam omitidos quando vazios, o que não acontece com tags "a>b"
+
+type olxCaracteristicas struct {
+	Caracteristica []string `xml:"Caracteristica"`
+}
+
+type olxFotos struct {
+	Foto []olxFoto `xml:"Foto"`
+}
+
+type olxVideos struct {
+	Video []olxVideo `xml:"Video"`
+}
+
+type olxFoto struct {
+	URLArquivo string `xml:"URLArquivo"`
+	Titulo     string `xml:"Titulo,omitempty"`
+	Principal  int    `xml:"Principal"`
+}
+
+type olxVideo struct {
+	URL string `xml:"URL"`
+}
+
+// buildOlx monta o feed no formato de carga XML da OLX. Diferente do VRSync, os tipos e subtipos da Jetimob são
+// publicados como estão e imóveis apenas de temporada também são publicados.
+func buildOlx(ls []listing, _ Options) (any, error) {
+	f := olxFeed{Xsi: xsiNamespace}
+
+	for _, l := range ls {
+		if !l.sale && !l.rent && !l.season {
+			log.Warn().Int("id", l.id).Str("codigo", l.code).Msg("imóvel sem contrato, não publicado no feed")
+			continue
+		}
+
+		im := olxImovel{
+			CodigoImovel:          l.code,
+			TipoImovel:            l.propertyType,
+			SubTipoImovel:         l.subtype,
+			TituloImovel:          l.title,
+			Observacao:            l.description,
+			UF:                    l.stateAbbr,
+			Cidade:                l.city,
+			Bairro:                l.neighborhood,
+			Endereco:              l.street,
+			Numero:                l.number,
+			Complemento:           l.complement,
+			CEP:                   l.zipcode,
+			PrecoVenda:            optional(l.salePrice),
+			PrecoLocacao:          optional(l.rentPrice),
+			PrecoLocacaoTemporada: optional(l.seasonPrice),
+			PrecoCondominio:       optional(l.condoFee),
+			ValorIPTU:             optional(l.yearlyTax),
+			AreaUtil:              optional(l.livingArea),
+			AreaTotal:             optional(l.totalArea),
+			QtdDormitorios:        l.bedrooms,
+			QtdSuites:             l.suites,
+			QtdBanheiros:          l.bathrooms,
+			QtdVagas:              l.garages,
+			Andar:                 l.floor,
+			Latitude:              l.lat,
+			Longitude:             l.lng,
+			Destaque:              l.featured,
+		}
+
+		if im.AreaTotal == "" {
+			im.AreaTotal = optional(l.lotArea)
+		}
+
+		if len(l.features) > 0 {
+			im.Caracteristicas = &olxCaracteristicas{Caracteristica: l.features}
+		}
+
+		if len(l.images) > 0 {
+			im.Fotos = &olxFotos{}
+			for i, img := range l.images {
+				principal := 0
+				if i == 0 {
+					principal = 1
+				}
+
+				im.Fotos.Foto = append(im.Fotos.Foto, olxFoto{URLArquivo: img.url, Titulo: img.caption, Principal: principal})
+			}
+		}
+
+		if len(l.videos) > 0 {
+			im.Videos = &olxVideos{}
+			for _, v := range l.videos {
+				im.Videos.Video = append(im.Videos.Video, olxVideo{URL: v})
+			}
+		}
+
+		f.Imoveis = append(f.Imoveis, im)
+	}
+
+	return f, nil
+}
+
+func optional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return ftoa(*v)
+}
@@ -0,0 +1,242 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/model"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sitemapNamespace      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapImageNamespace = "http://www.google.com/schemas/sitemap-image/1.1"
+	// limites do protocolo de sitemaps e das extensões de imagem do Google
+	maxSitemapUrls   = 50_000
+	maxSitemapImages = 1_000
+)
+
+type urlSet struct {
+	XMLName    xml.Name     `xml:"urlset"`
+	Xmlns      string       `xml:"xmlns,attr"`
+	XmlnsImage string       `xml:"xmlns:image,attr"`
+	Urls       []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string         `xml:"loc"`
+	LastMod string         `xml:"lastmod,omitempty"`
+	Images  []sitemapImage `xml:"image:image"`
+}
+
+type sitemapImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapFile é um dos arquivos do sitemap.
+type SitemapFile struct {
+	Name string
+	Data []byte
+}
+
+// Code retorna o código do imóvel na Jetimob ou, se vazio, o id.
+func Code(p model.Property) string {
+	if p.IdentifierCode == "" {
+		return strconv.Itoa(p.Id)
+	}
+
+	return p.IdentifierCode
+}
+
+// PropertyURL substitui os marcadores {codigo} e {id} do template pelos dados do imóvel.
+func PropertyURL(template string, p model.Property) string {
+	return strings.NewReplacer(
+		"{codigo}", url.PathEscape(Code(p)),
+		"{id}", strconv.Itoa(p.Id),
+	).Replace(template)
+}
+
+func validatePropertyURL(template string) error {
+	if !strings.Contains(template, "{codigo}") && !strings.Contains(template, "{id}") {
+		return errors.New(fmt.Sprintf(`a URL base "%s" precisa conter o marcador {codigo} ou {id}`, template))
+	}
+
+	u, err := url.Parse(template)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New(fmt.Sprintf(`a URL base "%s" precisa ser absoluta (ex.: https://site/imovel/{codigo})`, template))
+	}
+
+	return nil
+}
+
+// checkDuplicateURLs retorna um erro com os imóveis que geram a mesma URL, como imóveis com o mesmo código quando a URL
+// base utiliza apenas o marcador {codigo}.
+func checkDuplicateURLs(ps []model.Property, template string) error {
+	ids := make(map[string][]string, len(ps))
+	var locs []string
+	for _, p := range ps {
+		loc := PropertyURL(template, p)
+		if _, ok := ids[loc]; !ok {
+			locs = append(locs, loc)
+		}
+
+		ids[loc] = append(ids[loc], strconv.Itoa(p.Id))
+	}
+
+	var duplicates []string
+	for _, loc := range locs {
+		if len(ids[loc]) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (imóveis %s)", loc, strings.Join(ids[loc], ", ")))
+		}
+	}
+
+	if len(duplicates) > 0 {
+		return errors.New(fmt.Sprintf("mais de um imóvel gera a mesma URL, utilize o marcador {id} na URL base: %s", strings.Join(duplicates, "; ")))
+	}
+
+	return nil
+}
+
+// BuildSitemaps gera o sitemap das páginas dos imóveis, com as imagens de cada imóvel. Se houver mais de 50.000 URLs,
+// o sitemap é dividido em arquivos sitemap-N.xml e o sitemap.xml passa a ser o índice desses arquivos.
+func BuildSitemaps(ps []model.Property, opts Options) ([]SitemapFile, error) {
+	if err := validatePropertyURL(opts.PropertyURL); err != nil {
+		return nil, err
+	}
+
+	if err := checkDuplicateURLs(ps, opts.PropertyURL); err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemapUrl, len(ps))
+	lastMods := make([]time.Time, len(ps))
+	for i, p := range ps {
+		urls[i] = sitemapUrl{Loc: PropertyURL(opts.PropertyURL, p)}
+		if !p.UpdatedAt.IsZero() {
+			lastMods[i] = p.UpdatedAt.Time
+			urls[i].LastMod = p.UpdatedAt.Format(time.RFC3339)
+		}
+
+		for _, img := range p.Images {
+			if img.Url == "" {
+				continue
+			}
+
+			if len(urls[i].Images) == maxSitemapImages {
+				break
+			}
+
+			urls[i].Images = append(urls[i].Images, sitemapImage{Loc: img.Url})
+		}
+	}
+
+	if len(urls) <= maxSitemapUrls {
+		data, err := encodeXml(urlSet{Xmlns: sitemapNamespace, XmlnsImage: sitemapImageNamespace, Urls: urls})
+		if err != nil {
+			return nil, err
+		}
+
+		return []SitemapFile{{"sitemap.xml", data}}, nil
+	}
+
+	base, err := sitemapBaseURL(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []SitemapFile
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	for start := 0; start < len(urls); start += maxSitemapUrls {
+		end := start + maxSitemapUrls
+		if end > len(urls) {
+			end = len(urls)
+		}
+
+		name := fmt.Sprintf("sitemap-%d.xml", len(files)+1)
+		data, err := encodeXml(urlSet{Xmlns: sitemapNamespace, XmlnsImage: sitemapImageNamespace, Urls: urls[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, SitemapFile{name, data})
+
+		ref := sitemapRef{Loc: base + name}
+		var last time.Time
+		for _, t := range lastMods[start:end] {
+			if t.After(last) {
+				last = t
+			}
+		}
+
+		if !last.IsZero() {
+			ref.LastMod = last.Format(time.RFC3339)
+		}
+
+		index.Sitemaps = append(index.Sitemaps, ref)
+	}
+
+	data, err := encodeXml(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]SitemapFile{{"sitemap.xml", data}}, files...), nil
+}
+
+// sitemapBaseURL retorna a URL onde os arquivos do sitemap são publicados, sempre terminada em "/".
+func sitemapBaseURL(opts Options) (string, error) {
+	base := opts.SitemapURL
+	if base == "" {
+		u, err := url.Parse(opts.PropertyURL)
+		if err != nil {
+			return "", err
+		}
+
+		base = u.Scheme + "://" + u.Host
+	}
+
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	return base, nil
+}
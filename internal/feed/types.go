@@ -0,0 +1,160 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/transform"
+	"strings"
+)
+
+// vrsyncTypes são os tipos de imóvel aceitos pelo VRSync, no formato "uso / tipo".
+var vrsyncTypes = []string{
+	"Residential / Agricultural",
+	"Residential / Apartment",
+	"Residential / Condo",
+	"Residential / Farm Ranch",
+	"Residential / Flat",
+	"Residential / Home",
+	"Residential / Kitnet",
+	"Residential / Land Lot",
+	"Residential / Penthouse",
+	"Residential / Sobrado",
+	"Residential / Village House",
+	"Commercial / Agricultural",
+	"Commercial / Building",
+	"Commercial / Business",
+	"Commercial / Consultorio",
+	"Commercial / Edificio Residencial",
+	"Commercial / Farm Ranch",
+	"Commercial / Industrial",
+	"Commercial / Land Lot",
+	"Commercial / Loja",
+	"Commercial / Office",
+	"Commercial / Residential Income",
+}
+
+// defaultVrsyncTypes relaciona o slug dos tipos e subtipos mais comuns da Jetimob com os tipos do VRSync.
+var defaultVrsyncTypes = map[string]string{
+	"apartamento":          "Residential / Apartment",
+	"garden":               "Residential / Apartment",
+	"casa":                 "Residential / Home",
+	"casa-de-condominio":   "Residential / Condo",
+	"casa-em-condominio":   "Residential / Condo",
+	"sobrado":              "Residential / Sobrado",
+	"cobertura":            "Residential / Penthouse",
+	"flat":                 "Residential / Flat",
+	"kitnet":               "Residential / Kitnet",
+	"kitinete":             "Residential / Kitnet",
+	"loft":                 "Residential / Kitnet",
+	"studio":               "Residential / Kitnet",
+	"terreno":              "Residential / Land Lot",
+	"lote":                 "Residential / Land Lot",
+	"chacara":              "Residential / Farm Ranch",
+	"sitio":                "Residential / Farm Ranch",
+	"fazenda":              "Residential / Farm Ranch",
+	"area-rural":           "Residential / Agricultural",
+	"sala":                 "Commercial / Office",
+	"sala-comercial":       "Commercial / Office",
+	"conjunto-comercial":   "Commercial / Office",
+	"escritorio":           "Commercial / Office",
+	"consultorio":          "Commercial / Consultorio",
+	"loja":                 "Commercial / Loja",
+	"ponto-comercial":      "Commercial / Business",
+	"galpao":               "Commercial / Industrial",
+	"deposito":             "Commercial / Industrial",
+	"pavilhao":             "Commercial / Industrial",
+	"predio":               "Commercial / Building",
+	"predio-comercial":     "Commercial / Building",
+	"terreno-comercial":    "Commercial / Land Lot",
+	"predio-residencial":   "Commercial / Edificio Residencial",
+	"casa-comercial":       "Commercial / Residential Income",
+	"fazenda-comercial":    "Commercial / Farm Ranch",
+	"area-rural-comercial": "Commercial / Agricultural",
+}
+
+// typeMapper resolve o tipo do VRSync de um imóvel. Os tipos configurados têm prioridade sobre os padrões e a
+// combinação tipo/subtipo tem prioridade sobre o tipo.
+type typeMapper map[string]string
+
+func newTypeMapper(overrides map[string]string) (typeMapper, error) {
+	m := make(typeMapper, len(defaultVrsyncTypes)+len(overrides))
+	for k, v := range defaultVrsyncTypes {
+		m[k] = v
+	}
+
+	for k, v := range overrides {
+		vt, ok := vrsyncType(v)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(`feed: tipo "%s" configurado para "%s" não existe no VRSync, utilize um de: %s`, v, k, strings.Join(vrsyncTypes, ", ")))
+		}
+
+		m[typeKey(k)] = vt
+	}
+
+	return m, nil
+}
+
+// typeKey normaliza chaves no formato "tipo" ou "tipo/subtipo".
+func typeKey(k string) string {
+	parts := strings.SplitN(k, "/", 2)
+	for i, p := range parts {
+		parts[i] = transform.Slug(p)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+func vrsyncType(v string) (string, bool) {
+	for _, t := range vrsyncTypes {
+		if strings.EqualFold(t, strings.TrimSpace(v)) {
+			return t, true
+		}
+	}
+
+	return "", false
+}
+
+func (m typeMapper) lookup(propertyType, subtype string) (string, bool) {
+	t, st := transform.Slug(propertyType), transform.Slug(subtype)
+	for _, k := range []string{t + "/" + st, st, t} {
+		if k == "" || k == "/" {
+			continue
+		}
+
+		if vt, ok := m[k]; ok {
+			return vt, true
+		}
+	}
+
+	return "", false
+}
+
+// usageType retorna o uso ("Residential" ou "Commercial") de um tipo do VRSync.
+func usageType(vt string) string {
+	return strings.TrimSpace(strings.SplitN(vt, "/", 2)[0])
+}
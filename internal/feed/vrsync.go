@@ -0,0 +1,293 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/alanwgt/jsync/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vrsyncNamespace      = "http://www.vivareal.com/schemas/1.0/VRSync"
+	vrsyncSchemaLocation = "http://www.vivareal.com/schemas/1.0/VRSync http://xml.vivareal.com/vrsync.xsd"
+	xsiNamespace         = "http://www.w3.org/2001/XMLSchema-instance"
+	areaUnit             = "square metres"
+	currency             = "BRL"
+)
+
+type vrFeed struct {
+	XMLName        xml.Name    `xml:"ListingDataFeed"`
+	Xmlns          string      `xml:"xmlns,attr"`
+	Xsi            string      `xml:"xmlns:xsi,attr"`
+	SchemaLocation string      `xml:"xsi:schemaLocation,attr"`
+	Header         vrHeader    `xml:"Header"`
+	Listings       []vrListing `xml:"Listings>Listing"`
+}
+
+type vrHeader struct {
+	Provider    string `xml:"Provider"`
+	Email       string `xml:"Email"`
+	ContactName string `xml:"ContactName,omitempty"`
+	PublishDate string `xml:"PublishDate"`
+	Telephone   string `xml:"Telephone,omitempty"`
+}
+
+type vrListing struct {
+	ListingID       string         `xml:"ListingID"`
+	Title           string         `xml:"Title"`
+	TransactionType string         `xml:"TransactionType"`
+	Featured        bool           `xml:"Featured"`
+	LastUpdateDate  string         `xml:"LastUpdateDate,omitempty"`
+	Media           *vrMedia       `xml:"Media,omitempty"`
+	Details         vrDetails      `xml:"Details"`
+	Location        vrLocation     `xml:"Location"`
+	ContactInfo     *vrContactInfo `xml:"ContactInfo,omitempty"`
+}
+
+type vrMediaItem struct {
+	Medium  string `xml:"medium,attr"`
+	Caption string `xml:"caption,attr,omitempty"`
+	Primary bool   `xml:"primary,attr,omitempty"`
+	Url     string `xml:",chardata"`
+}
+
+type vrValue struct {
+	Currency string `xml:"currency,attr,omitempty"`
+	Period   string `xml:"period,attr,omitempty"`
+	Unit     string `xml:"unit,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type vrDetails struct {
+	UsageType                 string      `xml:"UsageType"`
+	PropertyType              string      `xml:"PropertyType"`
+	Description               string      `xml:"Description"`
+	ListPrice                 *vrValue    `xml:"ListPrice,omitempty"`
+	RentalPrice               *vrValue    `xml:"RentalPrice,omitempty"`
+	PropertyAdministrationFee *vrValue    `xml:"PropertyAdministrationFee,omitempty"`
+	YearlyTax                 *vrValue    `xml:"YearlyTax,omitempty"`
+	LivingArea                *vrValue    `xml:"LivingArea,omitempty"`
+	LotArea                   *vrValue    `xml:"LotArea,omitempty"`
+	Bedrooms                  int         `xml:"Bedrooms"`
+	Bathrooms                 int         `xml:"Bathrooms"`
+	Suites                    int         `xml:"Suites"`
+	Garage                    *vrValue    `xml:"Garage,omitempty"`
+	UnitFloor                 *int64      `xml:"UnitFloor,omitempty"`
+	Features                  *vrFeatures `xml:"Features,omitempty"`
+}
+
+// os wrappers são ponteiros para que os elementos sejam omitidos quando vazios, o que não acontece com tags "a>b"
+
+type vrMedia struct {
+	Items []vrMediaItem `xml:"Item"`
+}
+
+type vrFeatures struct {
+	Features []string `xml:"Feature"`
+}
+
+type vrAbbreviated struct {
+	Abbreviation string `xml:"abbreviation,attr,omitempty"`
+	Name         string `xml:",chardata"`
+}
+
+type vrLocation struct {
+	DisplayAddress string         `xml:"displayAddress,attr"`
+	Country        vrAbbreviated  `xml:"Country"`
+	State          *vrAbbreviated `xml:"State,omitempty"`
+	City           string         `xml:"City,omitempty"`
+	Neighborhood   string         `xml:"Neighborhood,omitempty"`
+	Address        string         `xml:"Address,omitempty"`
+	StreetNumber   string         `xml:"StreetNumber,omitempty"`
+	Complement     string         `xml:"Complement,omitempty"`
+	PostalCode     string         `xml:"PostalCode,omitempty"`
+	Latitude       *float64       `xml:"Latitude,omitempty"`
+	Longitude      *float64       `xml:"Longitude,omitempty"`
+}
+
+type vrContactInfo struct {
+	Name      string `xml:"Name,omitempty"`
+	Email     string `xml:"Email,omitempty"`
+	Website   string `xml:"Website,omitempty"`
+	Telephone string `xml:"Telephone,omitempty"`
+}
+
+// buildVrsync monta o feed no formato VRSync, utilizado pelo VivaReal e pelo ZAP Imóveis.
+// https://developers.grupozap.com/feeds/vrsync/
+func buildVrsync(ls []listing, opts Options) (any, error) {
+	if opts.Provider == "" || opts.Email == "" {
+		return nil, errors.New("feed: o VRSync exige o nome e o e-mail do anunciante, configure feed.provider e feed.email")
+	}
+
+	types, err := newTypeMapper(opts.PropertyTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	f := vrFeed{
+		Xmlns:          vrsyncNamespace,
+		Xsi:            xsiNamespace,
+		SchemaLocation: vrsyncSchemaLocation,
+		Header: vrHeader{
+			Provider:    opts.Provider,
+			Email:       opts.Email,
+			ContactName: opts.ContactName,
+			PublishDate: opts.PublishDate.Format(time.RFC3339),
+			Telephone:   opts.Phone,
+		},
+	}
+
+	var contact *vrContactInfo
+	if opts.ContactName != "" || opts.Email != "" || opts.Website != "" || opts.Phone != "" {
+		contact = &vrContactInfo{Name: opts.ContactName, Email: opts.Email, Website: opts.Website, Telephone: opts.Phone}
+	}
+
+	for _, l := range ls {
+		transaction := vrsyncTransaction(l)
+		if transaction == "" {
+			log.Warn().Int("id", l.id).Str("codigo", l.code).Msg("imóvel sem contrato de venda ou locação, não publicado no feed")
+			continue
+		}
+
+		propertyType, ok := types.lookup(l.propertyType, l.subtype)
+		if !ok {
+			log.Warn().
+				Int("id", l.id).
+				Str("tipo", l.propertyType).
+				Str("subtipo", l.subtype).
+				Msg("tipo de imóvel sem correspondência no VRSync, configure feed.property_types. O imóvel não será publicado")
+			continue
+		}
+
+		vl := vrListing{
+			ListingID:       l.code,
+			Title:           l.title,
+			TransactionType: transaction,
+			Featured:        l.featured,
+			Details: vrDetails{
+				UsageType:                 usageType(propertyType),
+				PropertyType:              propertyType,
+				Description:               l.description,
+				ListPrice:                 price(l.salePrice, ""),
+				RentalPrice:               price(l.rentPrice, "Monthly"),
+				PropertyAdministrationFee: price(l.condoFee, ""),
+				YearlyTax:                 price(l.yearlyTax, ""),
+				LivingArea:                area(l.livingArea),
+				LotArea:                   area(l.lotArea),
+				Bedrooms:                  l.bedrooms,
+				Bathrooms:                 l.bathrooms,
+				Suites:                    l.suites,
+				UnitFloor:                 l.floor,
+			},
+			Location: vrLocation{
+				DisplayAddress: l.display,
+				Country:        vrAbbreviated{Abbreviation: "BR", Name: "Brasil"},
+				City:           l.city,
+				Neighborhood:   l.neighborhood,
+				Address:        l.street,
+				StreetNumber:   l.number,
+				Complement:     l.complement,
+				PostalCode:     l.zipcode,
+				Latitude:       l.lat,
+				Longitude:      l.lng,
+			},
+			ContactInfo: contact,
+		}
+
+		if !l.updatedAt.IsZero() {
+			vl.LastUpdateDate = l.updatedAt.Format(time.RFC3339)
+		}
+
+		if l.garages > 0 {
+			vl.Details.Garage = &vrValue{Type: "Parking Space", Value: strconv.Itoa(l.garages)}
+		}
+
+		if l.state != "" {
+			vl.Location.State = &vrAbbreviated{Abbreviation: l.stateAbbr, Name: l.state}
+		}
+
+		if strings.HasSuffix(propertyType, "Land Lot") {
+			if vl.Details.LotArea == nil {
+				vl.Details.LotArea = area(l.totalArea)
+			}
+		} else if vl.Details.LivingArea == nil {
+			vl.Details.LivingArea = area(l.totalArea)
+		}
+
+		if len(l.features) > 0 {
+			vl.Details.Features = &vrFeatures{Features: l.features}
+		}
+
+		if len(l.images)+len(l.videos) > 0 {
+			vl.Media = &vrMedia{}
+			for i, img := range l.images {
+				vl.Media.Items = append(vl.Media.Items, vrMediaItem{Medium: "image", Caption: img.caption, Primary: i == 0, Url: img.url})
+			}
+
+			for _, v := range l.videos {
+				vl.Media.Items = append(vl.Media.Items, vrMediaItem{Medium: "video", Url: v})
+			}
+		}
+
+		f.Listings = append(f.Listings, vl)
+	}
+
+	return f, nil
+}
+
+func vrsyncTransaction(l listing) string {
+	switch {
+	case l.sale && l.rent:
+		return "Sale/Rent"
+	case l.sale:
+		return "For Sale"
+	case l.rent:
+		return "For Rent"
+	}
+
+	return ""
+}
+
+func price(v *float64, period string) *vrValue {
+	if v == nil {
+		return nil
+	}
+
+	return &vrValue{Currency: currency, Period: period, Value: ftoa(*v)}
+}
+
+func area(v *float64) *vrValue {
+	if v == nil {
+		return nil
+	}
+
+	return &vrValue{Unit: areaUnit, Value: ftoa(*v)}
+}
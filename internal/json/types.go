@@ -95,7 +95,14 @@ func (jt *JTime) UnmarshalJSON(data []byte) (err error) {
 		return err
 	}
 
-	jt.Time, err = time.Parse("2006-01-02 15:04:05", s)
+	// a Jetimob envia "2006-01-02 15:04:05", mas as colunas jsonb gravadas pelo sync (ex.: calendário de temporada)
+	// guardam as datas no formato padrão do time.Time (RFC3339)
+	if jt.Time, err = time.Parse("2006-01-02 15:04:05", s); err != nil {
+		if t, rfcErr := time.Parse(time.RFC3339, s); rfcErr == nil {
+			jt.Time, err = t, nil
+		}
+	}
+
 	return err
 }
 
@@ -0,0 +1,281 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	j "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/json"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var errCopyUnsupported = errors.New("a tabela possui colunas calculadas por expressões SQL")
+
+func (j JSync) batchSize() int {
+	if j.config.DB.BatchSize <= 0 {
+		return config.DefaultBatchSize
+	}
+
+	return j.config.DB.BatchSize
+}
+
+// batches divide n itens em intervalos [start, end) de no máximo size itens.
+func batches(n, size int) [][2]int {
+	var bs [][2]int
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+
+		bs = append(bs, [2]int{start, end})
+	}
+
+	return bs
+}
+
+func (j JSync) tenantCondition() []exp.Expression {
+	if !j.multiTenant {
+		return nil
+	}
+
+	return []exp.Expression{goqu.C(j.GetTenantColumn()).Eq(j.currentTenant.Identifier)}
+}
+
+func (j JSync) deleteIds(tx *sql.Tx, l zerolog.Logger, table string, ids []int) error {
+	for i, b := range batches(len(ids), j.batchSize()) {
+		st := time.Now()
+		q, _, err := goqu.Delete(table).
+			Where(j.tenantCondition()...).
+			Where(goqu.C("id").In(ids[b[0]:b[1]])).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(q); err != nil {
+			return err
+		}
+
+		l.Debug().
+			Int("batch", i+1).
+			Ints("ids", ids[b[0]:b[1]]).
+			Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+			Msg("rows desatualizadas removidas")
+	}
+
+	return nil
+}
+
+func (j JSync) insertRows(tx *sql.Tx, l zerolog.Logger, table string, rows []map[any]any) error {
+	for i, b := range batches(len(rows), j.batchSize()) {
+		st := time.Now()
+		q, _, err := goqu.
+			Dialect("postgres").
+			Insert(table).
+			Rows(rows[b[0]:b[1]]).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(q); err != nil {
+			return err
+		}
+
+		l.Debug().
+			Int("batch", i+1).
+			Int("rows", b[1]-b[0]).
+			Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+			Msg("lote inserido")
+	}
+
+	return nil
+}
+
+// copyRows carrega as rows numa tabela temporária através de COPY FROM STDIN e depois substitui as rows da tabela de
+// destino pelas da tabela temporária. Se alguma coluna não puder ser enviada pelo COPY, errCopyUnsupported é retornado
+// antes de qualquer modificação no banco.
+func (j JSync) copyRows(tx *sql.Tx, l zerolog.Logger, table string, rows []map[any]any) error {
+	var cols []string
+	for c := range rows[0] {
+		cols = append(cols, c.(string))
+	}
+	sort.Strings(cols)
+
+	values := make([][]any, len(rows))
+	for ri, row := range rows {
+		values[ri] = make([]any, len(cols))
+		for ci, c := range cols {
+			v, err := copyValue(row[c])
+			if err != nil {
+				return fmt.Errorf(`coluna "%s": %w`, c, err)
+			}
+
+			values[ri][ci] = v
+		}
+	}
+
+	staging := fmt.Sprintf("jsync_staging_%s", strings.ReplaceAll(table, ".", "_"))
+	quotedCols := make([]string, len(cols))
+	for i, c := range cols {
+		quotedCols[i] = quoteIdentifier(c)
+	}
+	colList := strings.Join(quotedCols, ", ")
+
+	if _, err := tx.Exec(fmt.Sprintf(
+		"DROP TABLE IF EXISTS %[1]s; CREATE TEMP TABLE %[1]s (LIKE %[2]s INCLUDING DEFAULTS) ON COMMIT DROP",
+		quoteIdentifier(staging),
+		quoteIdentifier(table),
+	)); err != nil {
+		return err
+	}
+
+	for i, b := range batches(len(values), j.batchSize()) {
+		st := time.Now()
+		stmt, err := tx.Prepare(pq.CopyIn(staging, cols...))
+		if err != nil {
+			return err
+		}
+
+		for _, v := range values[b[0]:b[1]] {
+			if _, err = stmt.Exec(v...); err != nil {
+				stmt.Close()
+				return err
+			}
+		}
+
+		if _, err = stmt.Exec(); err != nil {
+			stmt.Close()
+			return err
+		}
+
+		if err = stmt.Close(); err != nil {
+			return err
+		}
+
+		l.Debug().
+			Int("batch", i+1).
+			Int("rows", b[1]-b[0]).
+			Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+			Msg("lote copiado para a tabela temporária")
+	}
+
+	st := time.Now()
+	if j.config.CmdCfg.Truncate {
+		l.Warn().Bool("truncate", true).Msg("truncando tabela")
+		if err := j.clearTable(tx, table, j.currentTenant.Identifier); err != nil {
+			return err
+		}
+	} else {
+		q := fmt.Sprintf("DELETE FROM %[1]s t USING %[2]s s WHERE t.id = s.id", quoteIdentifier(table), quoteIdentifier(staging))
+		var args []any
+		if j.multiTenant {
+			q += fmt.Sprintf(" AND t.%s = $1", quoteIdentifier(j.GetTenantColumn()))
+			args = append(args, j.currentTenant.Identifier)
+		}
+
+		if _, err := tx.Exec(q, args...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s",
+		quoteIdentifier(table),
+		colList,
+		colList,
+		quoteIdentifier(staging),
+	)); err != nil {
+		return err
+	}
+
+	l.Debug().
+		Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+		Msg("tabela temporária mesclada com a tabela de destino")
+
+	return nil
+}
+
+// copyValue converte os tipos dos modelos, que são escritos como literais SQL pelo goqu, para valores aceitos pelo
+// protocolo de COPY.
+func copyValue(v any) (any, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case json.CommaStrSlice:
+		ss := make(pq.StringArray, len(t))
+		for i, s := range t {
+			ss[i] = strings.TrimSpace(s)
+		}
+		return ss, nil
+	case json.StrSlice:
+		return copyValue(json.CommaStrSlice(t))
+	case []string:
+		return copyValue(json.CommaStrSlice(t))
+	case json.JTime:
+		return t.Time, nil
+	case exp.Expression:
+		return nil, errCopyUnsupported
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		// vetores de structs são colunas jsonb
+		if rv.IsNil() {
+			return "[]", nil
+		}
+
+		bs, err := j.Marshal(v)
+		return string(bs), err
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := dv.(exp.Expression); ok {
+			return nil, errCopyUnsupported
+		}
+
+		return dv, nil
+	}
+
+	return v, nil
+}
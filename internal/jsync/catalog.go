@@ -0,0 +1,432 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/export"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"sort"
+	"strings"
+)
+
+const (
+	CatalogEq       = "eq"       // igual ao valor, sem diferenciar maiúsculas em textos
+	CatalogGte      = "gte"      // maior ou igual ao valor
+	CatalogLte      = "lte"      // menor ou igual ao valor
+	CatalogContains = "contains" // o vetor contém o texto, sem diferenciar maiúsculas
+)
+
+// catalogRelations relaciona os campos da Jetimob que referenciam itens de outros recursos.
+var catalogRelations = map[string][]struct {
+	field    string
+	resource string
+}{
+	config.ResourceProperties: {
+		{"id_corretor", config.ResourceBrokers},
+		{"id_condominio", config.ResourceCondominiums},
+	},
+}
+
+// ErrCatalogQuery indica que a consulta não pode ser atendida com o mapeamento atual, como um filtro ou ordenação por
+// um campo que não é sincronizado.
+var ErrCatalogQuery = errors.New("consulta inválida")
+
+// CatalogFilter é um filtro da leitura dos recursos sincronizados. Field é o nome do campo na Jetimob (ex.:
+// endereco_cidade), convertido para a coluna do mapeamento.
+type CatalogFilter struct {
+	Field string
+	Op    string
+	Value any
+}
+
+// CatalogQuery descreve uma leitura paginada dos recursos sincronizados.
+type CatalogQuery struct {
+	Id      int   // se diferente de zero, apenas o item com o id é lido
+	Ids     []int // se não vazio, apenas os itens com os ids são lidos
+	Filters []CatalogFilter
+	Sort    []string // colunas do banco, com o prefixo "-" para ordem decrescente
+	Limit   int
+	Offset  int
+	After   []any // valores das colunas de CatalogSortKeys no último item da página anterior (paginação por keyset)
+}
+
+// CatalogRelation é uma coluna com o id de um item de outro recurso, como o corretor do imóvel.
+type CatalogRelation struct {
+	Column   string
+	Resource string
+}
+
+// InTenant retorna uma cópia do JSync no tenant com o identificador fornecido. Diferente de SetCurrentTenant, o
+// requester compartilhado não é alterado, permitindo ler os dados de vários tenants simultaneamente. Fora de ambientes
+// multi tenancy, o identificador é ignorado.
+func (j JSync) InTenant(identifier string) (JSync, bool) {
+	for _, t := range j.GetTenants() {
+		if j.multiTenant && t.Identifier != identifier {
+			continue
+		}
+
+		j.currentTenant = &t
+		j.mappings = j.config.Mappings.Merge(t.Mappings)
+		if j.multiTenant {
+			j.L = j.baseL.With().Str("tenant", t.Identifier).Logger()
+		}
+
+		return j, true
+	}
+
+	return j, false
+}
+
+// Contract retorna o nome do contrato na Jetimob (em minúsculas) e o nome gravado no banco, após o mapeamento de
+// contratos. name pode ser qualquer um dos dois.
+func (j JSync) Contract(name string) (jetimob string, local string) {
+	lower := strings.ToLower(name)
+	if local, ok := j.mappings.Contracts[lower]; ok {
+		return lower, local
+	}
+
+	for k, v := range j.mappings.Contracts {
+		if strings.ToLower(v) == lower {
+			return k, v
+		}
+	}
+
+	return lower, name
+}
+
+// CatalogColumns retorna as colunas lidas por ReadCatalog, na mesma ordem do DDL. As colunas de tipo desconhecido,
+// como as calculadas com SQL ou templates, são descritas como texto.
+func (j JSync) CatalogColumns(resource string) ([]export.Column, error) {
+	return catalogColumns(j.resource(resource))
+}
+
+func catalogColumns(r resource) ([]export.Column, error) {
+	fields, _, err := mapFields(r.model, r.mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(fields, func(i, k int) bool {
+		return fields[i].prop.index < fields[k].prop.index
+	})
+
+	var cols []export.Column
+	for _, f := range fields {
+		kind, _ := f.kind()
+		cols = append(cols, export.Column{Name: f.column, Type: exportTypes[kind]})
+	}
+
+	computed, err := computedColumns(r.mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range computed {
+		cols = append(cols, export.Column{Name: c.mapping.Column, Type: exportTypes[c.kind()]})
+	}
+
+	return cols, nil
+}
+
+// CatalogRelations retorna as colunas do recurso que referenciam itens de outros recursos, se mapeadas.
+func (j JSync) CatalogRelations(resource string) []CatalogRelation {
+	var rs []CatalogRelation
+	for _, rel := range catalogRelations[resource] {
+		if c, ok := columnName(j.resource(resource).mapping, rel.field); ok {
+			rs = append(rs, CatalogRelation{c, rel.resource})
+		}
+	}
+
+	return rs
+}
+
+// ReadCatalog lê do banco as rows do recurso no tenant atual, com as colunas mapeadas e calculadas. Apenas os imóveis
+// ativos são lidos. Também retorna o total de rows que atendem aos filtros, ignorando a paginação. Nas leituras por id,
+// o total não é contado e corresponde ao número de rows lidas.
+func (j JSync) ReadCatalog(resource string, q CatalogQuery) ([]map[string]any, int, error) {
+	r := j.resource(resource)
+	cols, err := documentColumns(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where := j.tenantCondition()
+	if r.name == config.ResourceProperties {
+		where = append(where, goqu.C("active").Eq(true))
+	}
+
+	id := idColumn(r)
+	if q.Id != 0 {
+		where = append(where, goqu.C(id).Eq(q.Id))
+	}
+
+	if len(q.Ids) > 0 {
+		where = append(where, goqu.C(id).In(q.Ids))
+	}
+
+	for _, f := range q.Filters {
+		c, ok := columnName(r.mapping, f.Field)
+		if !ok {
+			return nil, 0, fmt.Errorf(`%w: o campo "%s" não é sincronizado em %s`, ErrCatalogQuery, f.Field, r.name)
+		}
+
+		cond, err := catalogCondition(c, f)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		where = append(where, cond)
+	}
+
+	keys, err := catalogSort(cols, id, q.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := -1
+	if q.Id == 0 && len(q.Ids) == 0 {
+		query, _, err := goqu.From(r.table).Select(goqu.COUNT(goqu.Star())).Where(where...).ToSQL()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if total, err = j.count(query); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if q.After != nil {
+		after, err := catalogKeyset(keys, q.After)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		where = append(where, after)
+	}
+
+	sel := make([]any, len(cols))
+	for i, c := range cols {
+		sel[i] = goqu.C(c)
+	}
+
+	order := make([]exp.OrderedExpression, len(keys))
+	for i, k := range keys {
+		if k.desc {
+			order[i] = goqu.C(k.column).Desc().NullsLast()
+		} else {
+			order[i] = goqu.C(k.column).Asc().NullsLast()
+		}
+	}
+
+	ds := goqu.From(r.table).Select(sel...).Where(where...).Order(order...)
+	if q.Limit > 0 {
+		ds = ds.Limit(uint(q.Limit))
+	}
+
+	if q.Offset > 0 {
+		ds = ds.Offset(uint(q.Offset))
+	}
+
+	query, _, err := ds.ToSQL()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	docs, err := readDocuments(j.db, query, r.table, cols)
+	if total < 0 {
+		total = len(docs)
+	}
+
+	return docs, total, err
+}
+
+// ReadCatalogIds lê os itens do recurso com os ids fornecidos numa única consulta, indexados pelo id. Os ids sem item
+// no banco não estão no resultado.
+func (j JSync) ReadCatalogIds(resource string, ids []int) (map[int]map[string]any, error) {
+	items := make(map[int]map[string]any, len(ids))
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	docs, _, err := j.ReadCatalog(resource, CatalogQuery{Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	id := idColumn(j.resource(resource))
+	for _, d := range docs {
+		switch v := d[id].(type) {
+		case int64:
+			items[int(v)] = d
+		case float64:
+			items[int(v)] = d
+		}
+	}
+
+	return items, nil
+}
+
+// CatalogSortKeys retorna as colunas que ordenam a leitura com sort, na ordem da ordenação e incluindo o id. Os valores
+// dessas colunas no último item de uma página formam o After da página seguinte. Colunas de vetores e json não podem
+// ser utilizadas na paginação por keyset.
+func (j JSync) CatalogSortKeys(resource string, sort []string) ([]string, error) {
+	r := j.resource(resource)
+	cols, err := catalogColumns(r)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(cols))
+	types := make(map[string]export.Type, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+		types[c.Name] = c.Type
+	}
+
+	keys, err := catalogSort(names, idColumn(r), sort)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(keys))
+	for i, k := range keys {
+		if t := types[k.column]; t == export.TypeTextList || t == export.TypeJson {
+			return nil, fmt.Errorf(`%w: a paginação por cursor não permite ordenar pela coluna "%s"`, ErrCatalogQuery, k.column)
+		}
+
+		columns[i] = k.column
+	}
+
+	return columns, nil
+}
+
+func (j JSync) count(query string) (int, error) {
+	rows, err := j.db.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var total int
+	for rows.Next() {
+		if err = rows.Scan(&total); err != nil {
+			return 0, err
+		}
+	}
+
+	return total, rows.Err()
+}
+
+func catalogCondition(column string, f CatalogFilter) (exp.Expression, error) {
+	c := goqu.C(column)
+	switch f.Op {
+	case CatalogEq:
+		if s, ok := f.Value.(string); ok {
+			return goqu.Func("lower", c).Eq(strings.ToLower(s)), nil
+		}
+
+		return c.Eq(f.Value), nil
+	case CatalogGte:
+		return c.Gte(f.Value), nil
+	case CatalogLte:
+		return c.Lte(f.Value), nil
+	case CatalogContains:
+		return goqu.L("EXISTS (SELECT 1 FROM unnest(?) AS v WHERE lower(v) = lower(?))", c, f.Value), nil
+	}
+
+	return nil, errors.New(fmt.Sprintf(`operador de filtro "%s" desconhecido`, f.Op))
+}
+
+// catalogKey é uma coluna da ordenação da leitura. Os valores nulos são sempre os últimos.
+type catalogKey struct {
+	column string
+	desc   bool
+}
+
+// catalogSort converte as colunas de ordenação, validando-as contra as colunas lidas. O id é sempre adicionado como
+// último critério para que a paginação seja estável.
+func catalogSort(cols []string, id string, sort []string) ([]catalogKey, error) {
+	known := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		known[c] = true
+	}
+
+	var keys []catalogKey
+	hasId := false
+	for _, s := range sort {
+		name := strings.TrimPrefix(s, "-")
+		if !known[name] {
+			return nil, fmt.Errorf(`%w: não é possível ordenar pela coluna "%s"`, ErrCatalogQuery, name)
+		}
+
+		hasId = hasId || name == id
+		keys = append(keys, catalogKey{name, strings.HasPrefix(s, "-")})
+	}
+
+	if !hasId {
+		keys = append(keys, catalogKey{id, false})
+	}
+
+	return keys, nil
+}
+
+// catalogKeyset gera a condição das rows que vêm depois de after na ordenação keys, equivalente a
+// (a, b, id) > (x, y, z) mas respeitando a direção de cada coluna e os nulos no final.
+func catalogKeyset(keys []catalogKey, after []any) (exp.Expression, error) {
+	if len(after) != len(keys) {
+		return nil, fmt.Errorf("%w: o cursor não corresponde à ordenação", ErrCatalogQuery)
+	}
+
+	var next []exp.Expression
+	var equal []exp.Expression
+	for i, k := range keys {
+		c := goqu.C(k.column)
+		if after[i] == nil {
+			// apenas outros nulos vêm depois de um nulo
+			equal = append(equal, c.IsNull())
+			continue
+		}
+
+		var gt exp.Expression = c.Gt(after[i])
+		if k.desc {
+			gt = c.Lt(after[i])
+		}
+
+		cond := append(append([]exp.Expression{}, equal...), goqu.Or(gt, c.IsNull()))
+		next = append(next, goqu.And(cond...))
+		equal = append(equal, c.Eq(after[i]))
+	}
+
+	if len(next) == 0 {
+		return nil, fmt.Errorf("%w: o cursor não corresponde à ordenação", ErrCatalogQuery)
+	}
+
+	return goqu.Or(next...), nil
+}
@@ -0,0 +1,391 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"strings"
+	"time"
+)
+
+// childTable é uma tabela com rows derivadas de um campo dos itens de um recurso (ex.: o calendário de temporada dos
+// imóveis), mantida na mesma transação que a tabela do recurso.
+type childTable struct {
+	table   string
+	parent  string      // coluna que referencia o id do item
+	columns []ddlColumn // colunas além da chave primária, da coluna parent e da coluna de tenant
+	// key identifica as rows de um item. Se informada, as rows são mescladas pela chave ao invés de substituídas,
+	// preservando as colunas em managed
+	key []string
+	// managed são colunas mantidas por outros sistemas (ex.: um CMS), apenas criadas pelo DDL
+	managed []ddlColumn
+	// rows retorna as rows do item, sem as colunas parent e de tenant
+	rows func(l zerolog.Logger, v model.Model) []map[any]any
+}
+
+// merged indica se as rows da tabela são mescladas pela chave.
+func (t childTable) merged() bool {
+	return len(t.key) > 0
+}
+
+// childRows são as rows de uma tabela filha geradas a partir dos itens sincronizados.
+type childRows struct {
+	childTable
+	rows []map[any]any
+}
+
+// childTables retorna as tabelas filhas configuradas para o recurso.
+func (j JSync) childTables(resource string) []childTable {
+	var ts []childTable
+	if c, ok := j.seasonCalendarTable(resource); ok {
+		ts = append(ts, c)
+	}
+
+	if m, ok := j.mediaTable(resource); ok {
+		ts = append(ts, m)
+	}
+
+	return ts
+}
+
+// mappedChildRows gera as rows das tabelas filhas do recurso a partir dos itens.
+func mappedChildRows[T model.Model](j JSync, values []T, r resource) []childRows {
+	var crs []childRows
+	for _, t := range j.childTables(r.name) {
+		l := j.L.With().Str("table", t.table).Logger()
+		cr := childRows{childTable: t}
+		for _, v := range values {
+			for _, row := range t.rows(l, v) {
+				row[t.parent] = v.Identifier()
+				if j.multiTenant {
+					row[j.GetTenantColumn()] = j.currentTenant.Identifier
+				}
+
+				cr.rows = append(cr.rows, row)
+			}
+		}
+
+		crs = append(crs, cr)
+	}
+
+	return crs
+}
+
+// deleteChildren remove as rows das tabelas filhas que referenciam os ids em pks e removed ou, ao truncar, todas as rows
+// do tenant. As rows são removidas antes das rows dos itens, para não violar as chaves estrangeiras. Nas tabelas
+// mescladas, as rows dos itens em pks são mantidas para a mesclagem e, ao truncar, apenas as rows dos demais itens do
+// tenant são removidas.
+func (j JSync) deleteChildren(tx *sql.Tx, crs []childRows, pks []int, removed []int) error {
+	for _, cr := range crs {
+		l := j.L.With().Str("table", cr.table).Logger()
+		if cr.merged() {
+			if err := j.deleteMergedChildren(tx, cr, pks, removed); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if j.config.CmdCfg.Truncate {
+			if err := j.clearTable(tx, cr.table, j.currentTenant.Identifier); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		ids := append(append([]int(nil), pks...), removed...)
+		if err := j.deleteChildrenOf(tx, cr.table, cr.parent, ids); err != nil {
+			return err
+		}
+
+		l.Debug().Int("items", len(ids)).Msg("rows da tabela filha removidas")
+	}
+
+	return nil
+}
+
+// deleteMergedChildren remove as rows da tabela mesclada que referenciam os itens removidos ou, ao truncar, os itens
+// que não serão escritos.
+func (j JSync) deleteMergedChildren(tx *sql.Tx, cr childRows, pks []int, removed []int) error {
+	if !j.config.CmdCfg.Truncate {
+		return j.deleteChildrenOf(tx, cr.table, cr.parent, removed)
+	}
+
+	// sem rows, a tabela do recurso não é truncada
+	if len(pks) == 0 {
+		return nil
+	}
+
+	q, _, err := goqu.Delete(cr.table).
+		Where(j.tenantCondition()...).
+		Where(goqu.C(cr.parent).NotIn(pks)).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(q)
+	return err
+}
+
+// deleteChildrenOf remove as rows da tabela filha que referenciam os ids fornecidos.
+func (j JSync) deleteChildrenOf(tx *sql.Tx, table string, parent string, ids []int) error {
+	for _, b := range batches(len(ids), j.batchSize()) {
+		q, _, err := goqu.Delete(table).
+			Where(j.tenantCondition()...).
+			Where(goqu.C(parent).In(ids[b[0]:b[1]])).
+			ToSQL()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeChildren escreve as rows das tabelas filhas, depois das rows dos itens com os ids em pks.
+func (j JSync) writeChildren(tx *sql.Tx, crs []childRows, pks []int) error {
+	for _, cr := range crs {
+		l := j.L.With().Str("table", cr.table).Logger()
+		var err error
+		if cr.merged() {
+			err = j.mergeChildren(tx, l, cr, pks)
+		} else {
+			err = j.insertRows(tx, l, cr.table, cr.rows)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		l.Info().Int("rows", len(cr.rows)).Msg("tabela filha sincronizada")
+	}
+
+	return nil
+}
+
+// mergeChildren carrega as rows numa tabela temporária e as mescla com as rows dos itens em pks pela chave da tabela:
+// as rows que deixaram de existir são removidas, as existentes têm apenas as colunas escritas pelo jsync atualizadas e
+// as novas são inseridas.
+func (j JSync) mergeChildren(tx *sql.Tx, l zerolog.Logger, cr childRows, pks []int) error {
+	st := time.Now()
+	staging := fmt.Sprintf("jsync_staging_%s", strings.ReplaceAll(cr.table, ".", "_"))
+	if _, err := tx.Exec(fmt.Sprintf(
+		"DROP TABLE IF EXISTS %[1]s; CREATE TEMP TABLE %[1]s (LIKE %[2]s INCLUDING DEFAULTS) ON COMMIT DROP",
+		quoteIdentifier(staging),
+		quoteIdentifier(cr.table),
+	)); err != nil {
+		return err
+	}
+
+	if err := j.insertRows(tx, l, staging, cr.rows); err != nil {
+		return err
+	}
+
+	var match []string
+	for _, c := range append([]string{cr.parent}, cr.key...) {
+		match = append(match, fmt.Sprintf("t.%[1]s = s.%[1]s", quoteIdentifier(c)))
+	}
+	matches := strings.Join(match, " AND ")
+
+	keys := make(map[string]bool, len(cr.key))
+	for _, k := range cr.key {
+		keys[k] = true
+	}
+
+	cols := []string{quoteIdentifier(cr.parent)}
+	var set []string
+	for _, c := range cr.columns {
+		cols = append(cols, quoteIdentifier(c.name))
+		if !keys[c.name] {
+			set = append(set, fmt.Sprintf("%[1]s = s.%[1]s", quoteIdentifier(c.name)))
+		}
+	}
+
+	tenant := ""
+	args := []any{pq.Array(pks)}
+	if j.multiTenant {
+		cols = append(cols, quoteIdentifier(j.GetTenantColumn()))
+		tenant = fmt.Sprintf(" AND t.%s = $2", quoteIdentifier(j.GetTenantColumn()))
+		args = append(args, j.currentTenant.Identifier)
+	}
+	colList := strings.Join(cols, ", ")
+
+	table := quoteIdentifier(cr.table)
+	queries := []string{fmt.Sprintf(
+		"DELETE FROM %s t WHERE t.%s = ANY($1)%s AND NOT EXISTS (SELECT 1 FROM %s s WHERE %s)",
+		table, quoteIdentifier(cr.parent), tenant, quoteIdentifier(staging), matches,
+	)}
+
+	if len(set) > 0 {
+		queries = append(queries, fmt.Sprintf(
+			"UPDATE %s t SET %s FROM %s s WHERE %s AND t.%s = ANY($1)%s",
+			table, strings.Join(set, ", "), quoteIdentifier(staging), matches, quoteIdentifier(cr.parent), tenant,
+		))
+	}
+
+	for _, q := range queries {
+		if _, err := tx.Exec(q, args...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO %[1]s (%[2]s) SELECT %[2]s FROM %[3]s s WHERE NOT EXISTS (SELECT 1 FROM %[1]s t WHERE %[4]s)",
+		table, colList, quoteIdentifier(staging), matches,
+	)); err != nil {
+		return err
+	}
+
+	l.Debug().
+		Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+		Msg("tabela temporária mesclada com a tabela filha")
+
+	return nil
+}
+
+// ApplyChildTables cria as tabelas filhas configuradas no tenant atual que ainda não existem, com o mesmo DDL de
+// GenerateSchema. As tabelas filhas são opcionais e por isso não fazem parte das migrações. A coluna de tenant utiliza o
+// tipo INT, o mesmo das migrações.
+func (j JSync) ApplyChildTables() error {
+	return j.db.ExecInTx(func(tx *sql.Tx) error {
+		for _, r := range j.resources() {
+			for _, t := range j.childTables(r.name) {
+				if _, err := tx.Exec(j.childTableDDL(t, r.table, "INT")); err != nil {
+					return fmt.Errorf(`falha ao criar a tabela "%s": %w`, t.table, err)
+				}
+
+				j.L.Info().Str("table", t.table).Msg("tabela filha criada, se inexistente")
+			}
+		}
+
+		return nil
+	})
+}
+
+// childTableDDL gera o CREATE TABLE da tabela filha, com a chave estrangeira para a tabela do recurso. As rows são
+// removidas junto com o item, exceto nas tabelas mescladas, cujas rows precisam sobreviver à reinserção do item.
+func (j JSync) childTableDDL(t childTable, parentTable string, tenantType string) string {
+	onDelete := " ON DELETE CASCADE"
+	if t.merged() {
+		onDelete = ""
+	}
+
+	cols := []ddlColumn{
+		{quoteIdentifier("id"), "BIGSERIAL PRIMARY KEY"},
+		{quoteIdentifier(t.parent), fmt.Sprintf(
+			"INT NOT NULL REFERENCES %s (%s)%s DEFERRABLE INITIALLY DEFERRED",
+			quoteIdentifier(parentTable),
+			quoteIdentifier("id"),
+			onDelete,
+		)},
+	}
+
+	for _, c := range append(append([]ddlColumn(nil), t.columns...), t.managed...) {
+		cols = append(cols, ddlColumn{quoteIdentifier(c.name), c.definition})
+	}
+
+	indexed := []string{t.parent}
+	if j.multiTenant {
+		cols = append(cols, ddlColumn{quoteIdentifier(j.GetTenantColumn()), tenantType + " NOT NULL"})
+		indexed = append([]string{j.GetTenantColumn()}, indexed...)
+	}
+
+	var b strings.Builder
+	writeCreateTable(&b, t.table, cols)
+
+	quoted := make([]string, len(indexed))
+	for i, c := range indexed {
+		quoted[i] = quoteIdentifier(c)
+	}
+
+	tableName := t.table[strings.LastIndex(t.table, ".")+1:]
+	b.WriteString(fmt.Sprintf(
+		"\nCREATE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+		quoteIdentifier(fmt.Sprintf("%s_%s_idx", tableName, t.parent)),
+		quoteIdentifier(t.table),
+		strings.Join(quoted, ", "),
+	))
+
+	if t.merged() {
+		quoted = []string{quoteIdentifier(t.parent)}
+		for _, c := range t.key {
+			quoted = append(quoted, quoteIdentifier(c))
+		}
+
+		b.WriteString(fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+			quoteIdentifier(fmt.Sprintf("%s_key_idx", tableName)),
+			quoteIdentifier(t.table),
+			strings.Join(quoted, ", "),
+		))
+	}
+
+	return b.String()
+}
+
+// checkChildTables verifica se as tabelas filhas do recurso existem e possuem as colunas escritas pelo jsync.
+func (j JSync) checkChildTables(r resource) ([]SchemaIssue, error) {
+	var issues []SchemaIssue
+	for _, t := range j.childTables(r.name) {
+		cols, err := j.tableColumns(t.table)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cols) == 0 {
+			issues = append(issues, SchemaIssue{Table: t.table, Kind: IssueMissingTable})
+			continue
+		}
+
+		required := []string{t.parent}
+		for _, c := range t.columns {
+			required = append(required, c.name)
+		}
+
+		if j.multiTenant {
+			required = append(required, j.GetTenantColumn())
+		}
+
+		for _, c := range required {
+			if _, ok := cols[c]; !ok {
+				issues = append(issues, SchemaIssue{Table: t.table, Column: c, Kind: IssueMissingColumn})
+			}
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// clearTable remove os dados da tabela. Em ambientes multi tenancy, apenas as rows do tenant fornecido são removidas.
+func (j JSync) clearTable(tx *sql.Tx, table string, tenant string) error {
+	exp := goqu.Delete(table)
+	if j.multiTenant {
+		exp = exp.Where(goqu.C(j.GetTenantColumn()).Eq(tenant))
+	}
+
+	q, _, err := exp.ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(q)
+	return err
+}
+
+// ClearResources remove os dados dos recursos fornecidos (todos, se nenhum for fornecido). Em ambientes multi tenancy,
+// apenas os dados dos tenants configurados (ou do tenant selecionado pela flag --tenant) são removidos, respeitando as
+// tabelas configuradas para cada tenant.
+func (j JSync) ClearResources(tx *sql.Tx, resources ...string) error {
+	if err := j.checkClearReferences(resources); err != nil {
+		return err
+	}
+
+	tenants := []config.TenantMapping{{}}
+	if j.multiTenant {
+		tenants = j.GetTenants()
+	}
+
+	for _, t := range tenants {
+		jt := j
+		jt.mappings = j.config.Mappings.Merge(t.Mappings)
+
+		// os recursos são removidos na ordem inversa da sincronização, imóveis antes de condomínios e corretores
+		rs := jt.filterResources(resources...)
+		for i := len(rs) - 1; i >= 0; i-- {
+			for _, c := range jt.childTables(rs[i].name) {
+				if err := j.clearTable(tx, c.table, t.Identifier); err != nil {
+					return err
+				}
+
+				j.L.Info().Str("table", c.table).Str("tenant", t.Identifier).Msg("dados removidos")
+			}
+
+			if err := j.clearTable(tx, rs[i].table, t.Identifier); err != nil {
+				return err
+			}
+
+			j.L.Info().Str("table", rs[i].table).Str("tenant", t.Identifier).Msg("dados removidos")
+		}
+	}
+
+	return nil
+}
+
+// checkClearReferences impede, com as chaves estrangeiras habilitadas, a remoção de corretores ou condomínios sem a
+// remoção dos imóveis, que falharia apenas no commit.
+func (j JSync) checkClearReferences(resources []string) error {
+	if !j.config.Integrity.ForeignKeys || len(resources) == 0 {
+		return nil
+	}
+
+	cleared := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		cleared[r] = true
+	}
+
+	if cleared[config.ResourceProperties] {
+		return nil
+	}
+
+	for _, ref := range propertyReferences {
+		if cleared[ref.resource] {
+			return errors.New(fmt.Sprintf(
+				"os imóveis referenciam %s através das chaves estrangeiras (integrity.foreign_keys), remova também os imóveis",
+				ref.resource,
+			))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/transform"
+	"github.com/doug-martin/goqu/v9"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TenantTemplateKey é a chave com o identificador do tenant nos templates das colunas calculadas.
+const TenantTemplateKey = "tenant"
+
+var templateFuncs = template.FuncMap{
+	"slug":  transform.Slug,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"now":   templateNow,
+	"join":  func(sep string, ss []string) string { return strings.Join(ss, sep) },
+	"contains": func(v any, s string) bool {
+		switch t := v.(type) {
+		case []string:
+			for _, i := range t {
+				if strings.EqualFold(i, s) {
+					return true
+				}
+			}
+		case string:
+			return strings.Contains(strings.ToLower(t), strings.ToLower(s))
+		}
+
+		return false
+	},
+	"default": func(def any, v any) any {
+		if v == nil || v == "" {
+			return def
+		}
+
+		return v
+	},
+}
+
+// templateTime é uma data nos templates. É escrita no formato RFC3339, sem o relógio monotônico do time.Time, e mantém
+// os métodos do time.Time (ex.: {{now.Format "2006-01-02"}}).
+type templateTime struct {
+	time.Time
+}
+
+func (t templateTime) String() string {
+	return t.Format(time.RFC3339)
+}
+
+// templateNow retorna a data atual em UTC, sem frações de segundo.
+func templateNow() templateTime {
+	return templateTime{time.Now().UTC().Truncate(time.Second)}
+}
+
+type computedColumn struct {
+	key       string
+	mapping   config.ColumnMapping
+	template  *template.Template
+	transform transform.Func
+}
+
+// computedColumns retorna as colunas calculadas do mapeamento, com os templates e transformações já compilados.
+func computedColumns(colMap map[string]any) ([]computedColumn, error) {
+	var cs []computedColumn
+	for key, v := range colMap {
+		cm, err := config.ParseColumnMapping(key, v)
+		if err != nil {
+			return nil, err
+		}
+
+		if !cm.Computed() {
+			continue
+		}
+
+		c := computedColumn{key: key, mapping: cm}
+		if cm.Template != "" {
+			c.template, err = template.New(key).Funcs(templateFuncs).Option("missingkey=error").Parse(cm.Template)
+			if err != nil {
+				return nil, fmt.Errorf(`template da coluna "%s": %w`, key, err)
+			}
+		}
+
+		if len(cm.Transforms) > 0 {
+			if c.transform, err = transform.Pipeline(cm.Transforms); err != nil {
+				return nil, fmt.Errorf(`coluna "%s": %w`, key, err)
+			}
+		}
+
+		cs = append(cs, c)
+	}
+
+	sort.Slice(cs, func(i, k int) bool {
+		return cs[i].key < cs[k].key
+	})
+
+	return cs, nil
+}
+
+// validateTemplates executa os templates com os campos de um item vazio do modelo, para que chaves inexistentes e
+// erros de execução sejam reportados ao carregar a configuração, e não durante a sincronização.
+func validateTemplates(cs []computedColumn, model any) error {
+	data := templateData(model, "")
+	for _, c := range cs {
+		if c.template == nil {
+			continue
+		}
+
+		if err := c.template.Execute(io.Discard, data); err != nil {
+			return fmt.Errorf(`template da coluna "%s": %w`, c.key, err)
+		}
+	}
+
+	return nil
+}
+
+func hasTemplate(cs []computedColumn) bool {
+	for _, c := range cs {
+		if c.template != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// templateData retorna os campos do item indexados pela chave do mapeamento (ex.: `tipo`, `codigo`), além do
+// identificador do tenant. Valores nulos são representados por textos vazios, números decimais são formatados sem
+// notação científica (ex.: 1500000) e datas são escritas no formato RFC3339.
+func templateData(v any, tenant string) map[string]any {
+	data := map[string]any{TenantTemplateKey: tenant}
+	rv := reflect.ValueOf(v)
+	for tag, prop := range extractTagMap(v) {
+		value := transform.Unwrap(rv.FieldByName(prop.Name()).Interface())
+		switch t := value.(type) {
+		case nil:
+			value = ""
+		case float64:
+			value = strconv.FormatFloat(t, 'f', -1, 64)
+		case time.Time:
+			value = templateTime{t}
+		}
+
+		tagSplit := strings.Split(tag, ".")
+		data[tagSplit[len(tagSplit)-1]] = value
+	}
+
+	return data
+}
+
+// eval calcula o valor da coluna. O resultado de um template vazio é escrito como nulo.
+func (c computedColumn) eval(data map[string]any) (any, error) {
+	var v any
+	switch {
+	case c.mapping.SQL != "":
+		return goqu.L(c.mapping.SQL), nil
+	case c.template != nil:
+		var b bytes.Buffer
+		if err := c.template.Execute(&b, data); err != nil {
+			return nil, err
+		}
+
+		if s := strings.TrimSpace(b.String()); s != "" {
+			v = s
+		}
+	default:
+		v = c.mapping.Value
+	}
+
+	if c.transform != nil {
+		return c.transform(v)
+	}
+
+	return v, nil
+}
+
+// kind retorna como a coluna é escrita no banco. Apenas constantes têm o tipo conhecido.
+func (c computedColumn) kind() sqlKind {
+	if c.template != nil || c.mapping.SQL != "" {
+		return kindUnknown
+	}
+
+	k, _ := transformedKind(valueKind(c.mapping.Value), c.mapping.Value == nil, c.mapping.Transforms)
+	return k
+}
@@ -0,0 +1,217 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"sort"
+	"strings"
+)
+
+const DialectPostgres = "postgres"
+
+var postgresTypes = map[sqlKind]string{
+	kindInt:       "INT",
+	kindFloat:     "NUMERIC",
+	kindBool:      "BOOL",
+	kindText:      "TEXT",
+	kindTextArray: "TEXT[]",
+	kindTime:      "TIMESTAMPTZ(3)",
+	kindJson:      "JSONB",
+}
+
+type ddlColumn struct {
+	name       string
+	definition string
+}
+
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// GenerateSchema gera os comandos CREATE TABLE de todos os recursos a partir dos modelos e do mapeamento configurado.
+// tenantType é o tipo da coluna de tenant, utilizado apenas em ambientes multi tenancy.
+func (j JSync) GenerateSchema(dialect string, tenantType string) (string, error) {
+	if dialect != DialectPostgres {
+		return "", errors.New(fmt.Sprintf(`dialeto "%s" não suportado, apenas "%s" está disponível`, dialect, DialectPostgres))
+	}
+
+	var b strings.Builder
+	if j.config.Geography.Enabled {
+		b.WriteString("CREATE EXTENSION IF NOT EXISTS postgis;\n\n")
+	}
+
+	for i, r := range j.resources() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		cols, err := j.ddlColumns(r, tenantType)
+		if err != nil {
+			return "", err
+		}
+
+		writeCreateTable(&b, r.table, cols)
+
+		tableName := r.table[strings.LastIndex(r.table, ".")+1:]
+		if j.multiTenant {
+			tenantCol := j.GetTenantColumn()
+			b.WriteString(fmt.Sprintf(
+				"\nCREATE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+				quoteIdentifier(fmt.Sprintf("%s_%s_idx", tableName, tenantCol)),
+				quoteIdentifier(r.table),
+				quoteIdentifier(tenantCol),
+			))
+		}
+
+		for _, c := range j.derivedColumns(r.name) {
+			b.WriteString(fmt.Sprintf(
+				"\nCREATE INDEX IF NOT EXISTS %s ON %s USING %s (%s);\n",
+				quoteIdentifier(fmt.Sprintf("%s_%s_idx", tableName, c.name)),
+				quoteIdentifier(r.table),
+				c.index,
+				quoteIdentifier(c.name),
+			))
+		}
+	}
+
+	// as tabelas filhas referenciam as tabelas dos recursos, então são criadas depois
+	for _, r := range j.resources() {
+		for _, t := range j.childTables(r.name) {
+			b.WriteString("\n" + j.childTableDDL(t, r.table, tenantType))
+		}
+	}
+
+	if j.config.Integrity.ForeignKeys {
+		b.WriteString("\n" + strings.Join(j.foreignKeysDDL(true), "\n") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// writeCreateTable escreve o CREATE TABLE com as colunas alinhadas.
+func writeCreateTable(b *strings.Builder, table string, cols []ddlColumn) {
+	width := 0
+	for _, c := range cols {
+		if len(c.name) > width {
+			width = len(c.name)
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s\n(\n", quoteIdentifier(table)))
+	for ci, c := range cols {
+		b.WriteString(fmt.Sprintf("  %-*s %s", width, c.name, c.definition))
+		if ci < len(cols)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(");\n")
+}
+
+func (j JSync) ddlColumns(r resource, tenantType string) ([]ddlColumn, error) {
+	fields, _, err := j.writtenFields(r, r.model)
+	if err != nil {
+		return nil, err
+	}
+
+	// mantém a ordem de declaração dos campos do modelo
+	sort.SliceStable(fields, func(i, k int) bool {
+		return fields[i].prop.index < fields[k].prop.index
+	})
+
+	var cols []ddlColumn
+	for _, f := range fields {
+		kind, nullable := f.kind()
+		t, ok := postgresTypes[kind]
+		if !ok && len(f.transforms) > 0 {
+			// o tipo resultante das transformações não pôde ser determinado
+			t, ok = postgresTypes[kindText], true
+		}
+
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(`não foi possível determinar o tipo SQL do campo "%s" (%s)`, f.key, f.prop.Type()))
+		}
+
+		if f.sqlType != "" {
+			t, ok = f.sqlType, true
+		}
+
+		def := t
+		switch {
+		case f.prop.Name() == "Id":
+			def += " PRIMARY KEY"
+		case nullable:
+			def += " NULL"
+		default:
+			def += " NOT NULL"
+		}
+
+		cols = append(cols, ddlColumn{quoteIdentifier(f.column), def})
+	}
+
+	computed, err := computedColumns(r.mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range computed {
+		t := c.mapping.Type
+		if t == "" {
+			switch {
+			case c.mapping.SQL != "":
+				return nil, errors.New(fmt.Sprintf(`informe o tipo ("type") da coluna calculada "%s" para gerar o DDL`, c.key))
+			case c.kind() != kindUnknown:
+				t = postgresTypes[c.kind()]
+			default:
+				t = postgresTypes[kindText]
+			}
+		}
+
+		cols = append(cols, ddlColumn{quoteIdentifier(c.mapping.Column), t + " NULL"})
+	}
+
+	for _, c := range j.derivedColumns(r.name) {
+		cols = append(cols, ddlColumn{quoteIdentifier(c.name), c.ddl + " NULL"})
+	}
+
+	if r.name == config.ResourceProperties {
+		cols = append(cols, ddlColumn{quoteIdentifier("active"), "BOOL NOT NULL DEFAULT false"})
+	}
+
+	if j.multiTenant {
+		cols = append(cols, ddlColumn{quoteIdentifier(j.GetTenantColumn()), tenantType + " NOT NULL"})
+	}
+
+	return cols, nil
+}
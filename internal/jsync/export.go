@@ -0,0 +1,206 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/export"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+	"reflect"
+	"sort"
+)
+
+// exportTypes relaciona os sqlKinds com os tipos exportados. Colunas de tipo desconhecido são exportadas como texto.
+var exportTypes = map[sqlKind]export.Type{
+	kindInt:       export.TypeInt,
+	kindFloat:     export.TypeFloat,
+	kindBool:      export.TypeBool,
+	kindText:      export.TypeText,
+	kindTextArray: export.TypeTextList,
+	kindTime:      export.TypeTime,
+	kindJson:      export.TypeJson,
+}
+
+// Export busca os itens do recurso na Jetimob e retorna as rows como seriam gravadas no banco, com os nomes de colunas
+// e as transformações do mapeamento. O banco de dados não é utilizado. Colunas escritas como expressões SQL (colunas
+// calculadas com "sql" e a coluna de busca) não são exportadas.
+func (j JSync) Export(resource string) ([]export.Column, [][]any, error) {
+	r := j.resource(resource)
+	switch resource {
+	case config.ResourceProperties:
+		ps, err := j.FetchProperties(false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids, err := j.requester.GetActiveProperties()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		active := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			active[id] = true
+		}
+
+		// a coluna active é preenchida pela sincronização de imóveis ativos, aqui ela é calculada junto com as rows
+		id := idColumn(r)
+		return exportRows(j, ps, r, func(m map[any]any) map[any]any {
+			m = j.remapPropertyRow(m)
+			if pk, ok := transform.Unwrap(m[id]).(int64); ok {
+				m["active"] = active[int(pk)]
+			}
+
+			return m
+		})
+	case config.ResourceCondominiums:
+		cs, err := j.requester.GetCondominiums()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return fetchedRows(j, cs, r)
+	case config.ResourceBrokers:
+		bs, err := j.requester.GetBrokers()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return fetchedRows(j, bs, r)
+	case config.ResourceBanners:
+		bs, err := j.requester.GetBanners()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return fetchedRows(j, bs, r)
+	}
+
+	return nil, nil, nil
+}
+
+// fetchedRows aplica os filtros e o espelhamento de mídias aos itens buscados, da mesma forma que a sincronização.
+func fetchedRows[T model.Model](j JSync, values []T, r resource) ([]export.Column, [][]any, error) {
+	values, _, err := filterValues(j, r.name, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mirrorMedia(j, r.name, values)
+
+	return exportRows(j, values, r, nil)
+}
+
+func exportRows[T model.Model](j JSync, values []T, r resource, beforeInsert BeforeInsertCallback) ([]export.Column, [][]any, error) {
+	cols, err := j.exportColumns(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(values) == 0 {
+		return cols, nil, nil
+	}
+
+	l := j.L.With().Str("resource", r.name).Logger()
+	inserts, _, err := mappedRows(j, l, values, r, beforeInsert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]any, len(inserts))
+	for i, m := range inserts {
+		rows[i] = make([]any, len(cols))
+		for ci, c := range cols {
+			rows[i][ci] = exportValue(m[c.Name])
+		}
+	}
+
+	l.Info().Int("rows", len(rows)).Msg("dados exportados")
+	return cols, rows, nil
+}
+
+// exportValue converte o valor para os tipos básicos aceitos pelo pacote export. Vetores nulos (como mídias ausentes)
+// são exportados como nulos.
+func exportValue(v any) any {
+	v = transform.Unwrap(v)
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil
+	}
+
+	return v
+}
+
+// exportColumns retorna as colunas exportadas do recurso, na mesma ordem do DDL gerado pelo jsync.
+func (j JSync) exportColumns(r resource) ([]export.Column, error) {
+	fields, _, err := j.writtenFields(r, r.model)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(fields, func(i, k int) bool {
+		return fields[i].prop.index < fields[k].prop.index
+	})
+
+	var cols []export.Column
+	for _, f := range fields {
+		kind, _ := f.kind()
+		cols = append(cols, export.Column{Name: f.column, Type: exportTypes[kind]})
+	}
+
+	computed, err := computedColumns(r.mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range computed {
+		if c.mapping.SQL != "" {
+			j.L.Debug().Str("column", c.mapping.Column).Msg("coluna calculada com expressão SQL não pode ser exportada, pulando")
+			continue
+		}
+
+		cols = append(cols, export.Column{Name: c.mapping.Column, Type: exportTypes[c.kind()]})
+	}
+
+	if c, ok := j.geographyColumn(r.name); ok {
+		// o ponto é exportado no formato EWKT
+		cols = append(cols, export.Column{Name: c, Type: export.TypeText})
+	}
+
+	if c, ok := j.searchColumn(r.name); ok {
+		j.L.Debug().Str("column", c).Msg("coluna de busca não pode ser exportada, pulando")
+	}
+
+	if r.name == config.ResourceProperties {
+		cols = append(cols, export.Column{Name: "active", Type: export.TypeBool})
+	}
+
+	if j.multiTenant {
+		cols = append(cols, export.Column{Name: j.GetTenantColumn(), Type: export.TypeText})
+	}
+
+	return cols, nil
+}
@@ -0,0 +1,302 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var filterDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+type rowFilter struct {
+	config.Filter
+	prop tagProp
+}
+
+// compileFilters resolve os campos dos filtros no modelo obj e valida os valores configurados.
+func compileFilters(obj any, fs []config.Filter) ([]rowFilter, error) {
+	tags := extractTagMap(obj)
+	t := reflect.TypeOf(obj)
+
+	rfs := make([]rowFilter, len(fs))
+	for i, f := range fs {
+		prop, ok := filterField(t, tags, f.Field)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(`filtro %d: campo "%s" desconhecido`, i+1, f.Field))
+		}
+
+		if f.Eq == nil && f.In == nil && f.Contains == nil && f.Min == nil && f.Max == nil {
+			return nil, errors.New(fmt.Sprintf(`filtro %d (%s): nenhuma condição configurada, utilize eq, in, contains, min ou max`, i+1, f.Field))
+		}
+
+		for _, b := range []any{f.Min, f.Max} {
+			if b == nil {
+				continue
+			}
+
+			if _, ok := filterNumber(b); ok {
+				continue
+			}
+
+			if _, ok := filterTime(b); !ok {
+				return nil, errors.New(fmt.Sprintf(`filtro %d (%s): o limite "%v" não é um número nem uma data`, i+1, f.Field, b))
+			}
+		}
+
+		rfs[i] = rowFilter{Filter: f, prop: prop}
+	}
+
+	return rfs, nil
+}
+
+// filterField encontra o campo pela chave da Jetimob ou pelo nome do campo no modelo.
+func filterField(t reflect.Type, tags map[string]tagProp, field string) (tagProp, bool) {
+	for tag, prop := range tags {
+		tagSplit := strings.Split(tag, ".")
+		if tagSplit[len(tagSplit)-1] == field {
+			return prop, true
+		}
+	}
+
+	sf, ok := t.FieldByName(field)
+	if !ok || len(sf.Index) != 1 {
+		return tagProp{}, false
+	}
+
+	return tagProp{name: sf.Name, t: sf.Type, index: sf.Index[0]}, true
+}
+
+func (f rowFilter) match(v any) bool {
+	value := transform.Unwrap(reflect.ValueOf(v).Field(f.prop.index).Interface())
+	return f.matchValue(value) != f.Not
+}
+
+func (f rowFilter) matchValue(v any) bool {
+	if f.Eq != nil && !filterEquals(v, f.Eq) {
+		return false
+	}
+
+	if f.In != nil {
+		in := false
+		for _, o := range f.In {
+			if filterEquals(v, o) {
+				in = true
+				break
+			}
+		}
+
+		if !in {
+			return false
+		}
+	}
+
+	if f.Contains != nil && !filterContains(v, f.Contains) {
+		return false
+	}
+
+	if f.Min != nil && !filterCompare(v, f.Min, func(c int) bool { return c >= 0 }) {
+		return false
+	}
+
+	if f.Max != nil && !filterCompare(v, f.Max, func(c int) bool { return c <= 0 }) {
+		return false
+	}
+
+	return true
+}
+
+// filterString normaliza o valor para comparações textuais.
+func filterString(v any) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+
+	return strings.TrimSpace(fmt.Sprint(transform.Unwrap(v)))
+}
+
+func filterEquals(v, o any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case []string:
+		for _, s := range t {
+			if filterEquals(s, o) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if a, ok := filterNumber(v); ok {
+		if b, ok := filterNumber(o); ok {
+			return a == b
+		}
+	}
+
+	return strings.EqualFold(filterString(v), filterString(o))
+}
+
+func filterContains(v, o any) bool {
+	switch t := v.(type) {
+	case []string:
+		return filterEquals(t, o)
+	case string:
+		return strings.Contains(strings.ToLower(t), strings.ToLower(filterString(o)))
+	}
+
+	return false
+}
+
+// filterCompare compara o valor com o limite, que pode ser um número ou uma data. Valores nulos nunca satisfazem o
+// filtro.
+func filterCompare(v, bound any, ok func(int) bool) bool {
+	if tv, isTime := v.(time.Time); isTime {
+		tb, valid := filterTime(bound)
+		if !valid {
+			return false
+		}
+
+		switch {
+		case tv.Before(tb):
+			return ok(-1)
+		case tv.After(tb):
+			return ok(1)
+		}
+
+		return ok(0)
+	}
+
+	a, valid := filterNumber(v)
+	if !valid {
+		return false
+	}
+
+	b, valid := filterNumber(bound)
+	if !valid {
+		return false
+	}
+
+	switch {
+	case a < b:
+		return ok(-1)
+	case a > b:
+		return ok(1)
+	}
+
+	return ok(0)
+}
+
+func filterNumber(v any) (float64, bool) {
+	switch t := transform.Unwrap(v).(type) {
+	case int64:
+		return float64(t), true
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func filterTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, layout := range filterDateLayouts {
+			if tt, err := time.Parse(layout, strings.TrimSpace(t)); err == nil {
+				return tt, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// resourceFilters retorna os filtros do recurso, considerando os filtros do tenant atual.
+func (j JSync) resourceFilters(resource string) []config.Filter {
+	fs := j.config.Filters
+	if j.currentTenant != nil {
+		fs = fs.Merge(j.currentTenant.Filters)
+	}
+
+	return fs[resource]
+}
+
+// filterValues aplica os filtros configurados para o recurso, retornando os itens que devem ser sincronizados e os
+// ids dos itens descartados.
+func filterValues[T model.Model](j JSync, resource string, values []T) ([]T, []int, error) {
+	fs := j.resourceFilters(resource)
+	if len(fs) == 0 {
+		return values, nil, nil
+	}
+
+	var zero T
+	rfs, err := compileFilters(zero, fs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("filtros de %s: %w", resource, err)
+	}
+
+	var kept []T
+	var filtered []int
+	for _, v := range values {
+		ok := true
+		for _, f := range rfs {
+			if !f.match(v) {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			kept = append(kept, v)
+		} else {
+			filtered = append(filtered, v.Identifier())
+		}
+	}
+
+	j.L.Info().
+		Str("resource", resource).
+		Int("kept", len(kept)).
+		Int("filtered", len(filtered)).
+		Msg("filtros aplicados")
+
+	return kept, filtered, nil
+}
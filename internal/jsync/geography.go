@@ -0,0 +1,179 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// metersPerDegree é a distância aproximada de um grau de latitude.
+const metersPerDegree = 111_320
+
+// geographyColumn retorna a coluna geography do recurso, se ela estiver habilitada.
+func (j JSync) geographyColumn(resource string) (string, bool) {
+	g := j.config.Geography
+	if !g.Enabled || resource != config.ResourceProperties && resource != config.ResourceCondominiums {
+		return "", false
+	}
+
+	if len(g.Resources) > 0 {
+		enabled := false
+		for _, r := range g.Resources {
+			enabled = enabled || r == resource
+		}
+
+		if !enabled {
+			return "", false
+		}
+	}
+
+	if g.Column == "" {
+		return config.DefaultGeographyColumn, true
+	}
+
+	return g.Column, true
+}
+
+func validateGeography(g config.Geography) error {
+	if !g.Enabled {
+		return nil
+	}
+
+	for _, r := range g.Resources {
+		if r != config.ResourceProperties && r != config.ResourceCondominiums {
+			return errors.New(fmt.Sprintf(`geography: recurso "%s" não possui coordenadas, utilize properties e/ou condominiums`, r))
+		}
+	}
+
+	for v, mode := range g.Visibility {
+		switch mode {
+		case config.GeographyExact, config.GeographyApproximate, config.GeographyHidden:
+		default:
+			return errors.New(fmt.Sprintf(`geography: modo "%s" da visibilidade %s desconhecido, utilize exact, approximate ou hidden`, mode, v))
+		}
+	}
+
+	if g.Jitter < 0 {
+		return errors.New("geography: o deslocamento (jitter) não pode ser negativo")
+	}
+
+	return validateGeographySecret(g)
+}
+
+// validateGeographySecret exige a chave do deslocamento se o modo approximate for utilizado. Sem ela, o deslocamento
+// poderia ser recalculado a partir do id do imóvel, que é público, revelando as coordenadas exatas.
+func validateGeographySecret(g config.Geography) error {
+	if g.Approximate() && g.Secret == "" {
+		return errors.New("geography: o modo approximate exige uma chave secreta (geography.secret) para o deslocamento")
+	}
+
+	return nil
+}
+
+// geographyValue retorna o ponto do item em EWKT, aceito pelo postgres tanto no INSERT quanto no COPY, ou nil se o item
+// não possuir coordenadas ou se a sua localização não puder ser exibida.
+func (j JSync) geographyValue(v any) any {
+	lat, lng, ok := j.Coordinates(v)
+	if !ok {
+		return nil
+	}
+
+	return fmt.Sprintf("SRID=4326;POINT(%s %s)", strconv.FormatFloat(lng, 'f', -1, 64), strconv.FormatFloat(lat, 'f', -1, 64))
+}
+
+// Coordinates retorna as coordenadas publicáveis do item conforme a sua visibilidade: deslocadas se a localização for
+// aproximada e ok falso se o item não possuir coordenadas ou se a localização não puder ser exibida.
+func (j JSync) Coordinates(v any) (lat, lng float64, ok bool) {
+	var id int
+	mode := config.GeographyExact
+
+	switch t := v.(type) {
+	case model.Property:
+		if !t.Latitude.Valid || !t.Longitude.Valid {
+			return 0, 0, false
+		}
+
+		id, lat, lng = t.Id, t.Latitude.Float64, t.Longitude.Float64
+		mode = j.config.Geography.Mode(strconv.Itoa(t.GeopositionVisibility))
+	case model.Condominium:
+		id, lat, lng = t.Id, t.Latitude, t.Longitude
+	default:
+		return 0, 0, false
+	}
+
+	if lat == 0 && lng == 0 || math.Abs(lat) > 90 || math.Abs(lng) > 180 {
+		return 0, 0, false
+	}
+
+	switch mode {
+	case config.GeographyHidden:
+		return 0, 0, false
+	case config.GeographyApproximate:
+		// sem a chave, o deslocamento seria previsível
+		if j.config.Geography.Secret == "" {
+			return 0, 0, false
+		}
+
+		radius := j.config.Geography.Jitter
+		if radius == 0 {
+			radius = config.DefaultGeographyJitter
+		}
+
+		lat, lng = jitter(j.config.Geography.Secret, id, lat, lng, radius)
+	}
+
+	return lat, lng, true
+}
+
+// jitter desloca as coordenadas em até radius metros. O deslocamento é determinado pelo HMAC do id do item com a chave
+// secreta, então o ponto não muda entre sincronizações, não pode ser descoberto pela média de vários deslocamentos e não
+// pode ser recalculado sem a chave.
+func jitter(secret string, id int, lat, lng, radius float64) (float64, float64) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(id)))
+	r := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(mac.Sum(nil)))))
+
+	// sqrt distribui os pontos uniformemente na área do círculo
+	distance := radius * math.Sqrt(r.Float64())
+	angle := 2 * math.Pi * r.Float64()
+
+	dLat := distance * math.Cos(angle) / metersPerDegree
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if cosLat < 1e-6 {
+		return lat + dLat, lng
+	}
+
+	return lat + dLat, lng + distance*math.Sin(angle)/(metersPerDegree*cosLat)
+}
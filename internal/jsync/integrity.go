@@ -0,0 +1,365 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/doug-martin/goqu/v9"
+	"strings"
+)
+
+// propertyReferences são as referências dos imóveis a itens de outros recursos.
+var propertyReferences = []struct {
+	resource string
+	field    string // campo do imóvel com a referência
+	id       func(p model.Property) (int, bool)
+}{
+	{config.ResourceBrokers, "id_corretor", func(p model.Property) (int, bool) {
+		return p.BrokerId, p.BrokerId != 0
+	}},
+	{config.ResourceCondominiums, "id_condominio", func(p model.Property) (int, bool) {
+		return int(p.CondominiumId.Int64), p.CondominiumId.Valid && p.CondominiumId.Int64 != 0
+	}},
+}
+
+// danglingReference é uma referência de imóveis a um item que não está no banco.
+type danglingReference struct {
+	resource   string
+	id         int
+	properties []int
+}
+
+// checkReferences verifica se os corretores e condomínios referenciados pelos imóveis estão no banco, relatando as
+// referências pendentes nos logs. Com fetch_missing, os itens ausentes são buscados na Jetimob e sincronizados antes
+// dos imóveis, na mesma transação. Com foreign_keys, as referências pendentes interrompem a sincronização antes da
+// escrita dos imóveis, já que as chaves estrangeiras fariam o commit falhar.
+func (j JSync) checkReferences(tx *sql.Tx, ps []model.Property) error {
+	if !j.config.Integrity.Enabled || len(ps) == 0 {
+		return nil
+	}
+
+	if !j.writesToDatabase(config.ResourceProperties) {
+		j.L.Debug().Msg("imóveis não são escritos no banco, referências não serão verificadas")
+		return nil
+	}
+
+	dangling, err := j.danglingReferences(tx, ps)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range dangling {
+		j.L.Warn().
+			Str("resource", d.resource).
+			Int("id", d.id).
+			Ints("properties", d.properties).
+			Msg("imóveis referenciam um item que não está no banco")
+	}
+
+	if len(dangling) == 0 {
+		j.L.Info().Msg("referências dos imóveis consistentes")
+		return nil
+	}
+
+	j.L.Warn().Int("references", len(dangling)).Msg("foram encontradas referências pendentes nos imóveis")
+	if !j.config.Integrity.ForeignKeys {
+		return nil
+	}
+
+	refs := make([]string, len(dangling))
+	for i, d := range dangling {
+		refs[i] = fmt.Sprintf("%s %d (imóveis %s)", d.resource, d.id, strings.Trim(fmt.Sprint(d.properties), "[]"))
+	}
+
+	return errors.New(fmt.Sprintf(
+		"as chaves estrangeiras (integrity.foreign_keys) impedem a sincronização, há referências pendentes: %s",
+		strings.Join(refs, "; "),
+	))
+}
+
+func (j JSync) danglingReferences(tx *sql.Tx, ps []model.Property) ([]danglingReference, error) {
+	var q queryer = j.db
+	if tx != nil {
+		q = tx
+	}
+
+	var dangling []danglingReference
+	for _, ref := range propertyReferences {
+		if !j.writesToDatabase(ref.resource) {
+			j.L.Debug().Str("resource", ref.resource).Msg("recurso não é escrito no banco, referências não serão verificadas")
+			continue
+		}
+
+		referenced := make(map[int][]int)
+		var ids []int
+		for _, p := range ps {
+			id, ok := ref.id(p)
+			if !ok {
+				continue
+			}
+
+			if _, seen := referenced[id]; !seen {
+				ids = append(ids, id)
+			}
+
+			referenced[id] = append(referenced[id], p.Id)
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		existing, err := j.existingIds(q, j.resource(ref.resource), ids)
+		if err != nil {
+			return nil, err
+		}
+
+		var missing []int
+		for _, id := range ids {
+			if !existing[id] {
+				missing = append(missing, id)
+			}
+		}
+
+		if len(missing) > 0 && j.config.Integrity.FetchMissing {
+			found, err := j.syncMissing(tx, ref.resource, missing)
+			if err != nil {
+				return nil, err
+			}
+
+			var pending []int
+			for _, id := range missing {
+				if !found[id] {
+					pending = append(pending, id)
+				}
+			}
+
+			missing = pending
+		}
+
+		for _, id := range missing {
+			dangling = append(dangling, danglingReference{ref.resource, id, referenced[id]})
+		}
+	}
+
+	return dangling, nil
+}
+
+// existingIds retorna quais dos ids estão na tabela do recurso, no tenant atual.
+func (j JSync) existingIds(q queryer, r resource, ids []int) (map[int]bool, error) {
+	existing := make(map[int]bool, len(ids))
+	id := idColumn(r)
+	for _, b := range batches(len(ids), j.batchSize()) {
+		query, _, err := goqu.From(r.table).
+			Select(goqu.C(id)).
+			Where(append(j.tenantCondition(), goqu.C(id).In(ids[b[0]:b[1]]))...).
+			ToSQL()
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := q.Query(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var v int
+			if err = rows.Scan(&v); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			existing[v] = true
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+// syncMissing busca os itens do recurso na Jetimob e sincroniza apenas os com os ids em missing, retornando os ids
+// encontrados. Itens descartados pelos filtros não são sincronizados.
+func (j JSync) syncMissing(tx *sql.Tx, resource string, missing []int) (map[int]bool, error) {
+	if !j.resourceEnabled(resource) {
+		return nil, nil
+	}
+
+	if j.config.CmdCfg.Truncate {
+		// a sincronização dos itens ausentes substituiria a tabela inteira
+		j.L.Warn().Str("resource", resource).Msg("itens ausentes não são buscados com truncate")
+		return nil, nil
+	}
+
+	want := make(map[int]bool, len(missing))
+	for _, id := range missing {
+		want[id] = true
+	}
+
+	j.L.Info().Str("resource", resource).Int("missing", len(missing)).Msg("buscando itens referenciados pelos imóveis")
+	switch resource {
+	case config.ResourceBrokers:
+		bs, err := j.requester.GetBrokers()
+		if err != nil {
+			return nil, err
+		}
+
+		return syncMissing(tx, j, resource, bs, want)
+	case config.ResourceCondominiums:
+		cs, err := j.requester.GetCondominiums()
+		if err != nil {
+			return nil, err
+		}
+
+		return syncMissing(tx, j, resource, cs, want)
+	}
+
+	return nil, nil
+}
+
+func syncMissing[T model.Model](tx *sql.Tx, j JSync, resource string, values []T, want map[int]bool) (map[int]bool, error) {
+	values, _, err := filterValues(j, resource, values)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[int]bool)
+	var missing []T
+	for _, v := range values {
+		if want[v.Identifier()] {
+			found[v.Identifier()] = true
+			missing = append(missing, v)
+		}
+	}
+
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	mirrorMedia(j, resource, missing)
+
+	return found, sync(tx, j, missing, nil, j.resource(resource), nil)
+}
+
+// foreignKey é uma chave estrangeira opcional dos imóveis.
+type foreignKey struct {
+	name       string
+	column     string
+	references string
+	refColumn  string
+}
+
+// foreignKeys retorna as chaves estrangeiras das referências dos imóveis cujas colunas estão mapeadas.
+func (j JSync) foreignKeys() []foreignKey {
+	properties := j.resource(config.ResourceProperties)
+	tableName := properties.table[strings.LastIndex(properties.table, ".")+1:]
+
+	var fks []foreignKey
+	for _, ref := range propertyReferences {
+		column, ok := columnName(properties.mapping, ref.field)
+		if !ok {
+			continue
+		}
+
+		r := j.resource(ref.resource)
+		fks = append(fks, foreignKey{
+			name:       fmt.Sprintf("%s_%s_fkey", tableName, column),
+			column:     column,
+			references: r.table,
+			refColumn:  idColumn(r),
+		})
+	}
+
+	return fks
+}
+
+// foreignKeysDDL gera os comandos que removem e, se add for verdadeiro, criam novamente as chaves estrangeiras dos
+// imóveis. As chaves só são verificadas no commit, então a remoção e reinserção das rows durante a sincronização não as
+// violam. NOT VALID não verifica as rows existentes.
+func (j JSync) foreignKeysDDL(add bool) []string {
+	table := quoteIdentifier(j.GetPropertiesTable())
+
+	var stmts []string
+	for _, fk := range j.foreignKeys() {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", table, quoteIdentifier(fk.name)))
+		if add {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) DEFERRABLE INITIALLY DEFERRED NOT VALID;",
+				table,
+				quoteIdentifier(fk.name),
+				quoteIdentifier(fk.column),
+				quoteIdentifier(fk.references),
+				quoteIdentifier(fk.refColumn),
+			))
+		}
+	}
+
+	return stmts
+}
+
+// ApplyForeignKeys cria as chaves estrangeiras da tabela de imóveis do tenant atual se integrity.foreign_keys estiver
+// habilitado e as remove caso contrário.
+func (j JSync) ApplyForeignKeys() error {
+	add := j.config.Integrity.ForeignKeys
+	err := j.db.ExecInTx(func(tx *sql.Tx) error {
+		for _, stmt := range j.foreignKeysDDL(add) {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	l := j.L.With().Str("table", j.GetPropertiesTable()).Logger()
+	if add {
+		l.Info().Msg("chaves estrangeiras dos imóveis criadas")
+	} else {
+		l.Debug().Msg("chaves estrangeiras dos imóveis desabilitadas, removidas se existentes")
+	}
+
+	return nil
+}
+
+func validateIntegrity(i config.Integrity) error {
+	if i.ForeignKeys && !i.Enabled {
+		return errors.New("integrity: foreign_keys exige integrity.enabled, para que as referências pendentes sejam relatadas antes do commit")
+	}
+
+	return nil
+}
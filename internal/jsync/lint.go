@@ -0,0 +1,67 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/lint"
+	"github.com/alanwgt/jsync/internal/model"
+)
+
+// Lint busca na Jetimob os imóveis e condomínios do tenant atual, aplicando os filtros da sincronização, e executa as
+// regras de qualidade dos anúncios. As mídias não são espelhadas e os recursos desabilitados para o tenant são
+// ignorados. Se activeOnly for verdadeiro, apenas os imóveis ativos são verificados.
+func (j JSync) Lint(l lint.Linter, activeOnly bool) (lint.Report, error) {
+	var ps []model.Property
+	if j.resourceEnabled(config.ResourceProperties) {
+		var err error
+		if ps, err = j.fetchProperties(activeOnly); err != nil {
+			return lint.Report{}, err
+		}
+	}
+
+	var cs []model.Condominium
+	if j.resourceEnabled(config.ResourceCondominiums) {
+		all, err := j.requester.GetCondominiums()
+		if err != nil {
+			return lint.Report{}, err
+		}
+
+		if cs, _, err = filterValues(j, config.ResourceCondominiums, all); err != nil {
+			return lint.Report{}, err
+		}
+	}
+
+	findings := l.Run(ps, cs)
+	j.L.Info().Int("properties", len(ps)).Int("condominiums", len(cs)).Int("findings", len(findings)).Msg("verificação de qualidade concluída")
+
+	var tenant string
+	if j.multiTenant {
+		tenant = j.currentTenant.Identifier
+	}
+
+	return lint.NewReport(tenant, findings), nil
+}
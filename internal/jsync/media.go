@@ -0,0 +1,116 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/media"
+	"github.com/alanwgt/jsync/internal/model"
+)
+
+// mediaRef aponta para a URL de uma mídia do item e, se a mídia suportar, para as suas variantes.
+type mediaRef struct {
+	url      *string
+	variants *[]model.MediaVariant
+}
+
+// mediaRefs retorna as mídias do item, permitindo que as URLs sejam reescritas.
+func mediaRefs(v any) []mediaRef {
+	var rs []mediaRef
+	switch t := v.(type) {
+	case *model.Property:
+		for _, ma := range []model.MediaArray{t.Images, t.Blueprints} {
+			for i := range ma {
+				rs = append(rs, mediaRef{&ma[i].Url, &ma[i].Variants}, mediaRef{url: &ma[i].ThumbnailUrl})
+			}
+		}
+	case *model.Condominium:
+		for _, ma := range []model.CondominiumMediaArray{t.Images, t.Blueprints} {
+			for i := range ma {
+				rs = append(rs, mediaRef{&ma[i].Url, &ma[i].Variants})
+			}
+		}
+
+		if t.CoverImage.Valid {
+			rs = append(rs, mediaRef{url: &t.CoverImage.String})
+		}
+	case *model.Banner:
+		if t.ImageUrl.Valid {
+			rs = append(rs, mediaRef{url: &t.ImageUrl.String})
+		}
+	}
+
+	return rs
+}
+
+// mirrorMedia espelha as mídias dos itens no storage configurado, reescreve as URLs dos itens e registra as variantes
+// geradas. As URLs que não puderem ser espelhadas são mantidas.
+func mirrorMedia[T model.Model](j JSync, resource string, values []T) {
+	if j.mirror == nil {
+		return
+	}
+
+	var rs []mediaRef
+	for i := range values {
+		rs = append(rs, mediaRefs(&values[i])...)
+	}
+
+	if len(rs) == 0 {
+		return
+	}
+
+	sources := make([]media.Source, len(rs))
+	for i, r := range rs {
+		sources[i] = media.Source{Url: *r.url, Variants: r.variants != nil}
+	}
+
+	l := j.L.With().Str("resource", resource).Logger()
+	l.Info().Int("urls", len(sources)).Msg("espelhando mídias")
+	mirrored := j.mirror.Mirror(l, sources)
+
+	for _, r := range rs {
+		m, ok := mirrored[*r.url]
+		if !ok {
+			continue
+		}
+
+		*r.url = m.Url
+		if r.variants == nil {
+			continue
+		}
+
+		vs := make([]model.MediaVariant, len(m.Variants))
+		for i, v := range m.Variants {
+			vs[i] = model.MediaVariant{
+				Name:   v.Name,
+				Url:    v.Url,
+				Width:  v.Width,
+				Height: v.Height,
+				Format: v.Format,
+			}
+		}
+		*r.variants = vs
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/rs/zerolog"
+	"gopkg.in/guregu/null.v4"
+)
+
+// tipos das mídias gravadas nas tabelas de mídias
+const (
+	MediaImage     = "image"
+	MediaBlueprint = "blueprint"
+	MediaVideo     = "video"
+	MediaTour      = "tour"
+)
+
+// mediaTable retorna a tabela filha das mídias do recurso, se configurada. As rows são mescladas pelo tipo e pela URL,
+// então as colunas caption e sort_order, mantidas por outros sistemas, sobrevivem às sincronizações.
+func (j JSync) mediaTable(resource string) (childTable, bool) {
+	var table *string
+	var parent string
+	switch resource {
+	case config.ResourceProperties:
+		table, parent = j.mappings.PropertyMediaTable, "property_id"
+	case config.ResourceCondominiums:
+		table, parent = j.mappings.CondominiumMediaTable, "condominium_id"
+	}
+
+	if table == nil || *table == "" {
+		return childTable{}, false
+	}
+
+	return childTable{
+		table:  *table,
+		parent: parent,
+		columns: []ddlColumn{
+			{"type", "TEXT NOT NULL"},
+			{"url", "TEXT NOT NULL"},
+			{"thumbnail", "TEXT NULL"},
+			{"title", "TEXT NULL"},
+			{"position", "INT NOT NULL"},
+		},
+		key: []string{"type", "url"},
+		managed: []ddlColumn{
+			{"caption", "TEXT NULL"},
+			{"sort_order", "INT NULL"},
+		},
+		rows: mediaRows,
+	}, true
+}
+
+// mediaItem é uma mídia do item, antes da numeração das posições.
+type mediaItem struct {
+	kind      string
+	url       string
+	thumbnail string
+	title     null.String
+}
+
+// mediaRows converte as imagens, plantas, vídeos e tours 360 do imóvel ou condomínio. A posição é a ordem da mídia
+// entre as mídias do mesmo tipo na Jetimob. As mídias sem URL e as URLs repetidas num mesmo tipo são ignoradas.
+func mediaRows(l zerolog.Logger, v model.Model) []map[any]any {
+	var items []mediaItem
+	switch t := v.(type) {
+	case model.Property:
+		for _, m := range []struct {
+			kind  string
+			media model.MediaArray
+		}{{MediaImage, t.Images}, {MediaBlueprint, t.Blueprints}} {
+			for _, i := range m.media {
+				items = append(items, mediaItem{m.kind, i.Url, i.ThumbnailUrl, i.Title})
+			}
+		}
+
+		for _, vd := range t.Videos {
+			items = append(items, mediaItem{kind: MediaVideo, url: vd.Url, title: vd.Title})
+		}
+
+		for _, tour := range t.ArTour {
+			items = append(items, mediaItem{kind: MediaTour, url: tour})
+		}
+	case model.Condominium:
+		for _, m := range []struct {
+			kind  string
+			media model.CondominiumMediaArray
+		}{{MediaImage, t.Images}, {MediaBlueprint, t.Blueprints}} {
+			for _, i := range m.media {
+				items = append(items, mediaItem{kind: m.kind, url: i.Url, title: i.Title})
+			}
+		}
+
+		for _, vd := range t.Videos {
+			items = append(items, mediaItem{kind: MediaVideo, url: vd.Url, title: vd.Title})
+		}
+
+		for _, tour := range t.ArTour {
+			items = append(items, mediaItem{kind: MediaTour, url: tour})
+		}
+	}
+
+	positions := make(map[string]int)
+	seen := make(map[mediaItem]bool)
+	var rows []map[any]any
+	for _, i := range items {
+		if i.url == "" {
+			continue
+		}
+
+		key := mediaItem{kind: i.kind, url: i.url}
+		if seen[key] {
+			l.Debug().Int("id", v.Identifier()).Str("type", i.kind).Str("url", i.url).Msg("mídia repetida, ignorando")
+			continue
+		}
+		seen[key] = true
+
+		rows = append(rows, map[any]any{
+			"type":      i.kind,
+			"url":       i.url,
+			"thumbnail": null.NewString(i.thumbnail, i.thumbnail != ""),
+			"title":     i.title,
+			"position":  positions[i.kind],
+		})
+		positions[i.kind]++
+	}
+
+	return rows
+}
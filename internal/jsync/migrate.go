@@ -0,0 +1,53 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/db"
+	"github.com/alanwgt/jsync/migrations"
+)
+
+// GetTenantColumn retorna a coluna de tenant configurada em tenant_column ou, se não configurada, a coluna padrão.
+func (j JSync) GetTenantColumn() string {
+	if c := j.config.TenantDiscriminatorColumn; c != nil && *c != "" {
+		return *c
+	}
+
+	return config.DefaultTenantColumn
+}
+
+// NewMigrator cria um migrador com as migrações embutidas no executável, renomeando as tabelas e a coluna de tenant
+// conforme a configuração.
+func (j JSync) NewMigrator() (*db.Migrator, error) {
+	return db.NewMigrator(j.db, migrations.FS, map[string]string{
+		config.DefaultPropertiesTable:   j.GetPropertiesTable(),
+		config.DefaultCondominiumsTable: j.GetCondominiumsTable(),
+		config.DefaultBannersTable:      j.GetBannersTable(),
+		config.DefaultBrokersTable:      j.GetBrokersTable(),
+		config.DefaultTenantColumn:      j.GetTenantColumn(),
+	})
+}
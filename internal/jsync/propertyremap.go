@@ -6,12 +6,13 @@ import (
 )
 
 func (j JSync) remapPropertyRow(row map[any]any) map[any]any {
-	contractsMapping := j.config.Mappings.Contracts
+	contractsMapping := j.mappings.Contracts
 	if contractsMapping == nil {
 		return row
 	}
 
-	contracts, ok := row[j.config.Mappings.Properties["contrato"]]
+	col, _ := columnName(j.mappings.Properties, "contrato")
+	contracts, ok := row[col]
 	if !ok {
 		j.L.Warn().Msg("mapeamento de contratos especificado, mas o mapeamento para a coluna de contratos não foi encontrado")
 		return row
@@ -0,0 +1,179 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"gopkg.in/guregu/null.v4"
+	"reflect"
+)
+
+// visibility associa um campo dos imóveis à sua visibilidade no anúncio, definida por model.Property.Visibility, a
+// mesma regra dos feeds.
+type visibility struct {
+	key     string
+	visible func(v model.Visibility) bool
+}
+
+var propertyVisibility = []visibility{
+	{"valor_venda", func(v model.Visibility) bool { return v.SaleValue }},
+	{"valor_locacao", func(v model.Visibility) bool { return v.RentalValue }},
+	{"valor_temporada", func(v model.Visibility) bool { return v.SeasonalValue }},
+	{"valor_iptu", func(v model.Visibility) bool { return v.IptuValue }},
+	{"valor_condominio", func(v model.Visibility) bool { return v.CondominiumValue }},
+	{"endereco_estado", func(v model.Visibility) bool { return v.State }},
+	{"id_estado", func(v model.Visibility) bool { return v.State }},
+	{"endereco_cidade", func(v model.Visibility) bool { return v.City }},
+	{"id_cidade", func(v model.Visibility) bool { return v.City }},
+	{"endereco_bairro", func(v model.Visibility) bool { return v.Neighborhood }},
+	{"id_bairro", func(v model.Visibility) bool { return v.Neighborhood }},
+	{"endereco_logradouro", func(v model.Visibility) bool { return v.Street }},
+	{"endereco_cep", func(v model.Visibility) bool { return v.Street }},
+	{"endereco_numero", func(v model.Visibility) bool { return v.Number }},
+	{"endereco_referencia", func(v model.Visibility) bool { return v.Reference }},
+	{"andar", func(v model.Visibility) bool { return v.Floor }},
+}
+
+// coordinateFields são ocultados ou deslocados conforme a visibilidade da localização (Coordinates).
+var coordinateFields = []string{"latitude", "longitude"}
+
+var propertyTags = extractTagMap(model.Property{})
+
+// hideable indica se o campo dos imóveis depende da visibilidade do anúncio.
+func hideable(key string) bool {
+	for _, v := range propertyVisibility {
+		if v.key == key {
+			return true
+		}
+	}
+
+	for _, c := range coordinateFields {
+		if c == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publicMode retorna a configuração do modo público, se habilitado para o recurso. Apenas os imóveis possuem
+// indicadores de visibilidade.
+func (j JSync) publicMode(r resource) (config.Public, bool) {
+	if r.name != config.ResourceProperties || j.mappings.Public == nil || !j.mappings.Public.Enabled {
+		return config.Public{}, false
+	}
+
+	return *j.mappings.Public, true
+}
+
+// writtenFields retorna os campos escritos na tabela do recurso. No modo público, os campos sujeitos à visibilidade
+// passam a aceitar nulos e, se configuradas, as suas colunas privadas são incluídas após os campos mapeados.
+func (j JSync) writtenFields(r resource, obj any) ([]mappedField, []string, error) {
+	fields, unmapped, err := mapFields(obj, r.mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	public, ok := j.publicMode(r)
+	if !ok {
+		return fields, unmapped, nil
+	}
+
+	var private []mappedField
+	for i, f := range fields {
+		if !hideable(f.key) {
+			continue
+		}
+
+		fields[i].hideable = true
+		if public.PrivateSuffix != "" {
+			p := f
+			p.column += public.PrivateSuffix
+			p.private = true
+			private = append(private, p)
+		}
+	}
+
+	return append(fields, private...), unmapped, nil
+}
+
+// validatePrivateColumns garante que as colunas privadas do modo público não coincidem com as demais colunas.
+func validatePrivateColumns(fields []mappedField, computed []computedColumn) error {
+	columns := make(map[string]bool)
+	for _, f := range fields {
+		if !f.private {
+			columns[f.column] = true
+		}
+	}
+
+	for _, c := range computed {
+		columns[c.mapping.Column] = true
+	}
+
+	for _, f := range fields {
+		if f.private && columns[f.column] {
+			return errors.New(fmt.Sprintf(`a coluna privada "%s" do campo "%s" já está mapeada, altere public.private_suffix`, f.column, f.key))
+		}
+	}
+
+	return nil
+}
+
+// publicItem retorna uma cópia do item com os campos ocultos zerados e as coordenadas publicáveis, utilizada no cálculo
+// das colunas no modo público, e as chaves dos campos ocultos, que são gravados como nulos.
+func publicItem[T model.Model](j JSync, v T) (T, map[string]bool) {
+	p, ok := any(v).(model.Property)
+	if !ok {
+		return v, nil
+	}
+
+	hidden := make(map[string]bool)
+	rv := reflect.ValueOf(&p).Elem()
+	visible := p.Visibility()
+	for _, vis := range propertyVisibility {
+		if vis.visible(visible) {
+			continue
+		}
+
+		hidden[vis.key] = true
+		f := rv.Field(propertyTags[vis.key].index)
+		f.Set(reflect.Zero(f.Type()))
+	}
+
+	if lat, lng, ok := j.Coordinates(v); ok {
+		p.Latitude, p.Longitude = null.FloatFrom(lat), null.FloatFrom(lng)
+	} else {
+		p.Latitude, p.Longitude = null.Float{}, null.Float{}
+		for _, c := range coordinateFields {
+			hidden[c] = true
+		}
+	}
+
+	return any(p).(T), hidden
+}
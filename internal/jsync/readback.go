@@ -0,0 +1,310 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	j "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/json"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v4"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FetchProperties busca os imóveis na Jetimob, aplicando os filtros e o espelhamento de mídias da mesma forma que a
+// sincronização. Se activeOnly for verdadeiro, apenas os imóveis ativos são retornados.
+func (j JSync) FetchProperties(activeOnly bool) ([]model.Property, error) {
+	ps, err := j.fetchProperties(activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	mirrorMedia(j, config.ResourceProperties, ps)
+
+	return ps, nil
+}
+
+// fetchProperties busca os imóveis na Jetimob e aplica os filtros da sincronização, sem espelhar as mídias.
+func (j JSync) fetchProperties(activeOnly bool) ([]model.Property, error) {
+	ps, err := j.requester.GetProperties(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, _, err = filterValues(j, config.ResourceProperties, ps)
+	if err != nil {
+		return nil, err
+	}
+
+	if activeOnly {
+		ids, err := j.requester.GetActiveProperties()
+		if err != nil {
+			return nil, err
+		}
+
+		active := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			active[id] = true
+		}
+
+		var kept []model.Property
+		for _, p := range ps {
+			if active[p.Id] {
+				kept = append(kept, p)
+			}
+		}
+
+		ps = kept
+	}
+
+	return ps, nil
+}
+
+// ReadProperties lê de volta os imóveis sincronizados no banco. Apenas as colunas mapeadas diretamente são lidas: os
+// campos com transformações ou sem mapeamento ficam com o valor zero. Os contratos remapeados voltam aos nomes da
+// Jetimob.
+func (j JSync) ReadProperties(activeOnly bool) ([]model.Property, error) {
+	var where []exp.Expression
+	if activeOnly {
+		where = append(where, goqu.C("active").Eq(true))
+	}
+
+	ps, err := readRows[model.Property](j, j.resource(config.ResourceProperties), where...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(j.mappings.Contracts) > 0 {
+		reverse := make(map[string]string, len(j.mappings.Contracts))
+		for jetimob, local := range j.mappings.Contracts {
+			reverse[strings.ToLower(local)] = jetimob
+		}
+
+		for i := range ps {
+			for ci, c := range ps[i].Contracts {
+				if original, ok := reverse[strings.ToLower(c)]; ok {
+					ps[i].Contracts[ci] = original
+				}
+			}
+		}
+	}
+
+	return ps, nil
+}
+
+// readRows lê as rows do tenant atual da tabela do recurso, preenchendo os campos do modelo a partir das colunas
+// mapeadas.
+func readRows[T model.Model](j JSync, r resource, where ...exp.Expression) ([]T, error) {
+	var zero T
+	fields, _, err := mapFields(zero, r.mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var readable []mappedField
+	var cols []any
+	for _, f := range fields {
+		if len(f.transforms) > 0 {
+			j.L.Debug().Str("column", f.column).Msg("coluna com transformações não pode ser lida de volta, pulando")
+			continue
+		}
+
+		readable = append(readable, f)
+		cols = append(cols, goqu.C(f.column))
+	}
+
+	if len(readable) == 0 {
+		return nil, errors.New(fmt.Sprintf("nenhuma coluna de %s pode ser lida de volta do banco", r.name))
+	}
+
+	q, _, err := goqu.From(r.table).
+		Select(cols...).
+		Where(append(j.tenantCondition(), where...)...).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := j.db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []T
+	dest := make([]any, len(readable))
+	raw := make([]any, len(readable))
+	for i := range dest {
+		dest[i] = &raw[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		var v T
+		rv := reflect.ValueOf(&v).Elem()
+		for i, f := range readable {
+			if err = assignColumn(rv.Field(f.prop.index), raw[i]); err != nil {
+				return nil, fmt.Errorf(`falha ao ler a coluna "%s" de %s: %w`, f.column, r.table, err)
+			}
+		}
+
+		values = append(values, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	j.L.Info().Str("table", r.table).Int("rows", len(values)).Msg("dados lidos do banco")
+
+	return values, nil
+}
+
+// assignColumn converte o valor lido do driver para o tipo do campo, fazendo o inverso da escrita feita pelo sync.
+func assignColumn(f reflect.Value, v any) error {
+	if v == nil {
+		return nil
+	}
+
+	switch f.Interface().(type) {
+	case null.Int, null.Float, null.Bool, null.String, null.Time:
+		return f.Addr().Interface().(interface{ Scan(any) error }).Scan(v)
+	case json.NullEmptyString:
+		var s null.String
+		if err := s.Scan(v); err != nil {
+			return err
+		}
+
+		f.Set(reflect.ValueOf(json.NullEmptyString(s)))
+		return nil
+	case json.JTime:
+		t, ok := v.(time.Time)
+		if !ok {
+			return errors.New(fmt.Sprintf("valor %v não é uma data", v))
+		}
+
+		f.Set(reflect.ValueOf(json.JTime{Time: t}))
+		return nil
+	case json.CommaStrSlice, json.StrSlice:
+		var ss pq.StringArray
+		if err := ss.Scan(v); err != nil {
+			return err
+		}
+
+		f.Set(reflect.ValueOf([]string(ss)).Convert(f.Type()))
+		return nil
+	case model.MediaArray:
+		var ms []model.MappedMedia
+		if err := unmarshalColumn(v, &ms); err != nil {
+			return err
+		}
+
+		media := make(model.MediaArray, len(ms))
+		for i, m := range ms {
+			media[i] = model.Media{Url: m.Url, ThumbnailUrl: m.ThumbnailUrl, Title: m.Title, Variants: m.Variants}
+		}
+
+		f.Set(reflect.ValueOf(media))
+		return nil
+	case model.VideoArray:
+		var vs []model.MappedVideo
+		if err := unmarshalColumn(v, &vs); err != nil {
+			return err
+		}
+
+		videos := make(model.VideoArray, len(vs))
+		for i, mv := range vs {
+			videos[i] = model.Video{Url: mv.Url, Title: mv.Title}
+		}
+
+		f.Set(reflect.ValueOf(videos))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(driverString(v), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		f.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(driverString(v), 64)
+		if err != nil {
+			return err
+		}
+
+		f.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(driverString(v))
+		if err != nil {
+			return err
+		}
+
+		f.SetBool(b)
+	case reflect.String:
+		f.SetString(driverString(v))
+	case reflect.Slice:
+		return unmarshalColumn(v, f.Addr().Interface())
+	default:
+		return errors.New(fmt.Sprintf("tipo %s não suportado", f.Type()))
+	}
+
+	return nil
+}
+
+func unmarshalColumn(v any, dst any) error {
+	bs, ok := v.([]byte)
+	if !ok {
+		bs = []byte(driverString(v))
+	}
+
+	return j.Unmarshal(bs, dst)
+}
+
+// driverString retorna a representação textual de um valor retornado pelo driver.
+func driverString(v any) string {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,100 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/json"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/doug-martin/goqu/v9/exp"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// TestReadBackSeasonCalendar escreve o calendário de temporada como o sync (SeasonCalendarArray.Value) e o lê de volta
+// como a leitura do banco (assignColumn).
+func TestReadBackSeasonCalendar(t *testing.T) {
+	written := model.SeasonCalendarArray{
+		{
+			Name:               null.StringFrom("Réveillon"),
+			StartDate:          json.JTime{Time: time.Date(2024, 12, 28, 0, 0, 0, 0, time.UTC)},
+			EndDate:            json.JTime{Time: time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)},
+			DailyRate:          null.FloatFrom(850.5),
+			MinimumDailyRental: null.IntFrom(5),
+		},
+		{
+			StartDate: json.JTime{Time: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+			EndDate:   json.JTime{Time: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	v, err := written.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// o sync escreve o literal '[...]'::jsonb e o driver lê de volta o texto do jsonb
+	stored := strings.TrimSuffix(strings.TrimPrefix(v.(exp.LiteralExpression).Literal(), "'"), "'::jsonb")
+
+	var p model.Property
+	if err = assignColumn(reflect.ValueOf(&p).Elem().FieldByName("SeasonCalendar"), []byte(stored)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.SeasonCalendar) != len(written) {
+		t.Fatalf("esperado %d períodos, obtido %d", len(written), len(p.SeasonCalendar))
+	}
+
+	for i, want := range written {
+		got := p.SeasonCalendar[i]
+		if !got.StartDate.Equal(want.StartDate.Time) || !got.EndDate.Equal(want.EndDate.Time) {
+			t.Errorf("período %d: datas esperadas %v - %v, obtidas %v - %v", i, want.StartDate, want.EndDate, got.StartDate, got.EndDate)
+		}
+
+		if got.Name != want.Name || got.DailyRate != want.DailyRate || got.MinimumDailyRental != want.MinimumDailyRental {
+			t.Errorf("período %d: esperado %+v, obtido %+v", i, want, got)
+		}
+	}
+}
+
+// TestReadBackJetimobTime garante que as datas no formato da Jetimob continuam sendo aceitas.
+func TestReadBackJetimobTime(t *testing.T) {
+	var jt json.JTime
+	if err := jt.UnmarshalJSON([]byte(`"2024-01-01 10:30:00"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC); !jt.Equal(want) {
+		t.Fatalf("esperado %v, obtido %v", want, jt.Time)
+	}
+
+	if err := jt.UnmarshalJSON([]byte(`"01/01/2024"`)); err == nil {
+		t.Fatal("data inválida aceita")
+	}
+}
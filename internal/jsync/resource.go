@@ -0,0 +1,111 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+)
+
+// resource descreve um recurso sincronizado: o modelo, o mapeamento de colunas e a tabela de destino.
+type resource struct {
+	name    string
+	model   model.Model
+	mapping map[string]any
+	table   string
+}
+
+func (j JSync) resources() []resource {
+	return []resource{
+		{config.ResourceBanners, model.Banner{}, j.mappings.Banners, j.GetBannersTable()},
+		{config.ResourceBrokers, model.Broker{}, j.mappings.Brokers, j.GetBrokersTable()},
+		{config.ResourceCondominiums, model.Condominium{}, j.mappings.Condominiums, j.GetCondominiumsTable()},
+		{config.ResourceProperties, model.Property{}, j.mappings.Properties, j.GetPropertiesTable()},
+	}
+}
+
+// resource retorna o recurso com o nome fornecido.
+func (j JSync) resource(name string) resource {
+	for _, r := range j.resources() {
+		if r.name == name {
+			return r
+		}
+	}
+
+	panic(fmt.Sprintf(`recurso "%s" desconhecido`, name))
+}
+
+// filterResources retorna apenas os recursos com os nomes fornecidos. Se nenhum nome for fornecido, todos são retornados.
+func (j JSync) filterResources(names ...string) []resource {
+	rs := j.resources()
+	if len(names) == 0 {
+		return rs
+	}
+
+	var filtered []resource
+	for _, r := range rs {
+		for _, n := range names {
+			if r.name == n {
+				filtered = append(filtered, r)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+func isResource(name string) bool {
+	for _, r := range config.Resources {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// derivedColumn é uma coluna calculada pelo jsync a partir de campos do item, como as colunas geography e tsvector.
+type derivedColumn struct {
+	name  string
+	ddl   string // tipo utilizado no DDL
+	udt   string // udt_name esperado no information_schema
+	index string // método do índice criado no DDL
+}
+
+func (j JSync) derivedColumns(resource string) []derivedColumn {
+	var cs []derivedColumn
+	if c, ok := j.geographyColumn(resource); ok {
+		cs = append(cs, derivedColumn{c, "GEOGRAPHY(Point, 4326)", "geography", "GIST"})
+	}
+
+	if c, ok := j.searchColumn(resource); ok {
+		cs = append(cs, derivedColumn{c, "TSVECTOR", "tsvector", "GIN"})
+	}
+
+	return cs
+}
@@ -0,0 +1,236 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"strings"
+)
+
+type SchemaIssueKind string
+
+const (
+	IssueMissingTable   SchemaIssueKind = "tabela inexistente"
+	IssueMissingColumn  SchemaIssueKind = "coluna inexistente"
+	IssueTypeMismatch   SchemaIssueKind = "tipo incompatível"
+	IssueNotNull        SchemaIssueKind = "coluna NOT NULL receberá nulos"
+	IssueRequiredColumn SchemaIssueKind = "coluna NOT NULL sem mapeamento"
+)
+
+// SchemaIssue é uma inconsistência entre o mapeamento configurado e o banco de dados.
+type SchemaIssue struct {
+	Table  string
+	Column string
+	Field  string
+	Kind   SchemaIssueKind
+	Detail string
+}
+
+type dbColumn struct {
+	udt        string
+	nullable   bool
+	hasDefault bool
+}
+
+// tableColumns retorna as colunas da tabela pelo information_schema. A tabela pode ser qualificada com o schema
+// (schema.tabela), caso contrário, o schema atual é utilizado.
+func (j JSync) tableColumns(table string) (map[string]dbColumn, error) {
+	var schema *string
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		s := table[:i]
+		schema, table = &s, table[i+1:]
+	}
+
+	rows, err := j.db.Query(`SELECT column_name, udt_name, is_nullable = 'YES', column_default IS NOT NULL
+FROM information_schema.columns
+WHERE table_schema = COALESCE($1, current_schema()) AND table_name = $2`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := make(map[string]dbColumn)
+	for rows.Next() {
+		var name string
+		var c dbColumn
+		if err = rows.Scan(&name, &c.udt, &c.nullable, &c.hasDefault); err != nil {
+			return nil, err
+		}
+
+		cols[name] = c
+	}
+
+	return cols, rows.Err()
+}
+
+// CheckSchema compara o mapeamento dos recursos fornecidos (todos, se nenhum for fornecido) com as tabelas do banco.
+func (j JSync) CheckSchema(resources ...string) ([]SchemaIssue, error) {
+	var issues []SchemaIssue
+
+	for _, r := range j.filterResources(resources...) {
+		if j.currentTenant != nil && !j.currentTenant.ResourceEnabled(r.name) {
+			continue
+		}
+
+		cols, err := j.tableColumns(r.table)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cols) == 0 {
+			issues = append(issues, SchemaIssue{Table: r.table, Kind: IssueMissingTable})
+			continue
+		}
+
+		fields, _, err := j.writtenFields(r, r.model)
+		if err != nil {
+			return nil, err
+		}
+
+		written := make(map[string]bool)
+		for _, f := range fields {
+			written[f.column] = true
+			issue := SchemaIssue{Table: r.table, Column: f.column, Field: f.key}
+
+			col, ok := cols[f.column]
+			if !ok {
+				issue.Kind = IssueMissingColumn
+				issues = append(issues, issue)
+				continue
+			}
+
+			kind, nullable := f.kind()
+			if kind != kindUnknown && !kind.acceptsUdt(col.udt) {
+				issue.Kind = IssueTypeMismatch
+				issue.Detail = fmt.Sprintf("%s (%s) não pode ser escrito em %s", f.prop.Type(), kind, col.udt)
+				issues = append(issues, issue)
+			}
+
+			if nullable && !col.nullable {
+				issue.Kind = IssueNotNull
+				issue.Detail = fmt.Sprintf("%s pode ser nulo", f.prop.Type())
+				issues = append(issues, issue)
+			}
+		}
+
+		computed, err := computedColumns(r.mapping)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range computed {
+			written[c.mapping.Column] = true
+			issue := SchemaIssue{Table: r.table, Column: c.mapping.Column, Field: c.key}
+
+			col, ok := cols[c.mapping.Column]
+			if !ok {
+				issue.Kind = IssueMissingColumn
+				issues = append(issues, issue)
+				continue
+			}
+
+			if kind := c.kind(); kind != kindUnknown && !kind.acceptsUdt(col.udt) {
+				issue.Kind = IssueTypeMismatch
+				issue.Detail = fmt.Sprintf("o valor constante (%s) não pode ser escrito em %s", kind, col.udt)
+				issues = append(issues, issue)
+			}
+		}
+
+		var required []string
+		if j.multiTenant {
+			required = append(required, j.GetTenantColumn())
+		}
+
+		if r.name == config.ResourceProperties {
+			// utilizada por MarkPropertiesAsActive
+			required = append(required, "active")
+		}
+
+		for _, c := range j.derivedColumns(r.name) {
+			written[c.name] = true
+			col, ok := cols[c.name]
+			switch {
+			case !ok:
+				issues = append(issues, SchemaIssue{Table: r.table, Column: c.name, Kind: IssueMissingColumn})
+			case col.udt != c.udt:
+				issues = append(issues, SchemaIssue{
+					Table:  r.table,
+					Column: c.name,
+					Kind:   IssueTypeMismatch,
+					Detail: fmt.Sprintf("a coluna precisa ser %s, não %s", c.ddl, col.udt),
+				})
+			}
+		}
+
+		for _, c := range required {
+			written[c] = true
+			if _, ok := cols[c]; !ok {
+				issues = append(issues, SchemaIssue{Table: r.table, Column: c, Kind: IssueMissingColumn})
+			}
+		}
+
+		for name, col := range cols {
+			if !written[name] && !col.nullable && !col.hasDefault {
+				issues = append(issues, SchemaIssue{Table: r.table, Column: name, Kind: IssueRequiredColumn})
+			}
+		}
+
+		children, err := j.checkChildTables(r)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, children...)
+	}
+
+	return issues, nil
+}
+
+// ValidateSchema executa CheckSchema e registra cada inconsistência encontrada, retornando um erro se houver alguma.
+func (j JSync) ValidateSchema(resources ...string) error {
+	issues, err := j.CheckSchema(resources...)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range issues {
+		j.L.Error().
+			Str("table", i.Table).
+			Str("column", i.Column).
+			Str("field", i.Field).
+			Str("detail", i.Detail).
+			Msg(string(i.Kind))
+	}
+
+	if len(issues) > 0 {
+		return errors.New(fmt.Sprintf("foram encontradas %d inconsistências entre o mapeamento e o banco de dados", len(issues)))
+	}
+
+	j.L.Info().Msg("mapeamento consistente com o banco de dados")
+	return nil
+}
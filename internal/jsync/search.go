@@ -0,0 +1,144 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+	"github.com/doug-martin/goqu/v9"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var searchConfigRegex = regexp.MustCompile(`^[a-z_][a-z0-9_.]*$`)
+
+type searchField struct {
+	key    string
+	weight string
+	prop   tagProp
+}
+
+// searchColumn retorna a coluna tsvector do recurso, se ela estiver habilitada. Apenas imóveis possuem a coluna.
+func (j JSync) searchColumn(resource string) (string, bool) {
+	s := j.config.Search
+	if !s.Enabled || resource != config.ResourceProperties {
+		return "", false
+	}
+
+	if s.Column == "" {
+		return config.DefaultSearchColumn, true
+	}
+
+	return s.Column, true
+}
+
+func (j JSync) searchConfig() string {
+	if j.config.Search.Config == "" {
+		return config.DefaultSearchConfig
+	}
+
+	return j.config.Search.Config
+}
+
+// searchFields resolve os campos indexados no modelo, ordenados pelo peso.
+func (j JSync) searchFields(obj any) ([]searchField, error) {
+	fieldWeights := j.config.Search.Fields
+	if len(fieldWeights) == 0 {
+		fieldWeights = config.DefaultSearchFields
+	}
+
+	if !searchConfigRegex.MatchString(j.searchConfig()) {
+		return nil, errors.New(fmt.Sprintf(`search: configuração de busca "%s" inválida`, j.searchConfig()))
+	}
+
+	tags := extractTagMap(obj)
+	t := reflect.TypeOf(obj)
+
+	var fs []searchField
+	for key, weight := range fieldWeights {
+		weight = strings.ToUpper(weight)
+		if len(weight) != 1 || weight < "A" || weight > "D" {
+			return nil, errors.New(fmt.Sprintf(`search: o peso "%s" do campo "%s" é inválido, utilize A, B, C ou D`, weight, key))
+		}
+
+		prop, ok := filterField(t, tags, key)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(`search: campo "%s" desconhecido`, key))
+		}
+
+		if kind, _ := kindOf(prop.Type()); kind != kindText && kind != kindTextArray {
+			return nil, errors.New(fmt.Sprintf(`search: o campo "%s" não é textual (%s)`, key, kind))
+		}
+
+		fs = append(fs, searchField{key: key, weight: weight, prop: prop})
+	}
+
+	sort.Slice(fs, func(i, k int) bool {
+		if fs[i].weight != fs[k].weight {
+			return fs[i].weight < fs[k].weight
+		}
+
+		return fs[i].key < fs[k].key
+	})
+
+	return fs, nil
+}
+
+// searchValue monta a expressão que calcula o tsvector do item, concatenando os campos com os seus pesos. Itens sem
+// nenhum texto recebem nulo.
+func (j JSync) searchValue(fields []searchField, v model.Model) any {
+	rv := reflect.ValueOf(v)
+
+	var parts []string
+	var args []any
+	for _, f := range fields {
+		var text string
+		switch t := transform.Unwrap(rv.Field(f.prop.index).Interface()).(type) {
+		case string:
+			text = t
+		case []string:
+			text = strings.Join(t, ", ")
+		}
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		parts = append(parts, "setweight(to_tsvector(?::regconfig, ?), ?)")
+		args = append(args, j.searchConfig(), text, f.weight)
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	return goqu.L(strings.Join(parts, " || "), args...)
+}
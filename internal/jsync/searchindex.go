@@ -0,0 +1,394 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	j "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// queryer é implementado por *sql.Tx e db.Db, permitindo ler os documentos dentro ou fora da transação do sync.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// searchIndexState guarda os ids dos imóveis sincronizados até que a atualização dos imóveis ativos defina quais
+// deles devem estar no índice. É um ponteiro para ser compartilhado entre as cópias do JSync.
+//
+// O índice não é um sink.Sink: os documentos são lidos do banco após a escrita do sink postgres e os imóveis só são
+// indexados após a atualização dos imóveis ativos, que não passa pelos sinks.
+type searchIndexState struct {
+	changed []int
+}
+
+// searchIndexResource retorna a configuração do índice do recurso, se a indexação estiver habilitada para ele.
+func (j JSync) searchIndexResource(resource string) (config.SearchIndexResource, bool) {
+	if j.searchIndex == nil {
+		return config.SearchIndexResource{}, false
+	}
+
+	r, ok := j.config.SearchIndex.Resources[resource]
+	return r, ok
+}
+
+// searchIndexName retorna o nome do índice do recurso. Em ambientes multi tenancy, cada tenant tem o seu índice.
+func (j JSync) searchIndexName(r resource) string {
+	name := j.config.SearchIndex.Resources[r.name].Index
+	if name == "" {
+		name = r.table[strings.LastIndex(r.table, ".")+1:]
+	}
+
+	if j.multiTenant {
+		name += "_" + j.currentTenant.Identifier
+	}
+
+	return name
+}
+
+// idColumn retorna a coluna para a qual o campo Id do modelo está mapeado (ex.: id_imovel nos imóveis), ou "" se o
+// campo não estiver mapeado.
+func idColumn(r resource) string {
+	for tag, prop := range extractTagMap(r.model) {
+		if prop.Name() == "Id" {
+			c, _ := columnName(r.mapping, tag[strings.LastIndex(tag, ".")+1:])
+			return c
+		}
+	}
+
+	return ""
+}
+
+// searchDocumentColumns retorna as colunas incluídas nos documentos: as configuradas ou todas as colunas mapeadas e
+// calculadas. A coluna id sempre é incluída.
+func (j JSync) searchDocumentColumns(r resource) ([]string, error) {
+	id := idColumn(r)
+	if fields := j.config.SearchIndex.Resources[r.name].Fields; len(fields) > 0 {
+		cols := []string{id}
+		for _, f := range fields {
+			if f != id {
+				cols = append(cols, f)
+			}
+		}
+
+		return cols, nil
+	}
+
+	return documentColumns(r)
+}
+
+// documentColumns retorna os nomes das colunas mapeadas e calculadas do recurso, na mesma ordem do DDL.
+func documentColumns(r resource) ([]string, error) {
+	typed, err := catalogColumns(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]string, len(typed))
+	for i, c := range typed {
+		cols[i] = c.Name
+	}
+
+	return cols, nil
+}
+
+// searchDocuments lê do banco os documentos das rows com os ids fornecidos ou, se ids for nil, de todas as rows do
+// tenant. Apenas imóveis ativos são indexados.
+func (j JSync) searchDocuments(q queryer, r resource, ids []int) ([]map[string]any, error) {
+	cols, err := j.searchDocumentColumns(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := make([]any, len(cols))
+	for i, c := range cols {
+		sel[i] = goqu.C(c)
+	}
+
+	where := j.tenantCondition()
+	if r.name == config.ResourceProperties {
+		where = append(where, goqu.C("active").Eq(true))
+	}
+
+	var docs []map[string]any
+	read := func(where []exp.Expression) error {
+		query, _, err := goqu.From(r.table).Select(sel...).Where(where...).ToSQL()
+		if err != nil {
+			return err
+		}
+
+		rows, err := readDocuments(q, query, r.table, cols)
+		docs = append(docs, rows...)
+		return err
+	}
+
+	if ids == nil {
+		return docs, read(where)
+	}
+
+	for _, b := range batches(len(ids), j.batchSize()) {
+		if err = read(append(where, goqu.C(idColumn(r)).In(ids[b[0]:b[1]]))); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// readDocuments executa a query e retorna as rows como documentos JSON, com as colunas em cols.
+func readDocuments(q queryer, query string, table string, cols []string) ([]map[string]any, error) {
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range dest {
+		dest[i] = &raw[i]
+	}
+
+	var docs []map[string]any
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		doc := make(map[string]any, len(cols))
+		for i, c := range cols {
+			if doc[c], err = documentValue(raw[i], types[i].DatabaseTypeName()); err != nil {
+				return nil, fmt.Errorf(`coluna "%s" de %s: %w`, c, table, err)
+			}
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// documentValue converte os valores lidos do driver que chegam como texto (numeric, json e vetores) para valores JSON.
+func documentValue(v any, dbType string) (any, error) {
+	bs, ok := v.([]byte)
+	if !ok {
+		return v, nil
+	}
+
+	switch {
+	case dbType == "NUMERIC":
+		return strconv.ParseFloat(string(bs), 64)
+	case dbType == "JSON" || dbType == "JSONB":
+		var parsed any
+		err := j.Unmarshal(bs, &parsed)
+		return parsed, err
+	case strings.HasPrefix(dbType, "_"):
+		var ss pq.StringArray
+		err := ss.Scan(bs)
+		return []string(ss), err
+	}
+
+	return string(bs), nil
+}
+
+// updateSearchIndex envia ao índice os documentos das rows upserted e remove os ids em removed. Falhas no índice não
+// interrompem a sincronização, o índice pode ser reconstruído com jsync reindex.
+func (j JSync) updateSearchIndex(q queryer, r resource, upserted []int, removed []int) {
+	if _, ok := j.searchIndexResource(r.name); !ok || len(upserted)+len(removed) == 0 {
+		return
+	}
+
+	index := j.searchIndexName(r)
+	l := j.L.With().Str("index", index).Logger()
+	err := func() error {
+		if len(upserted) > 0 {
+			docs, err := j.searchDocuments(q, r, upserted)
+			if err != nil {
+				return err
+			}
+
+			if err = j.searchIndex.Upsert(index, idColumn(r), docs); err != nil {
+				return err
+			}
+
+			l.Info().Int("documents", len(docs)).Msg("documentos atualizados no índice de busca")
+		}
+
+		if len(removed) > 0 {
+			if err := j.searchIndex.Delete(index, removed); err != nil {
+				return err
+			}
+
+			l.Info().Int("documents", len(removed)).Msg("documentos removidos do índice de busca")
+		}
+
+		return nil
+	}()
+
+	if err != nil {
+		l.Error().Err(err).Msg("falha ao atualizar o índice de busca, utilize jsync reindex para reconstruí-lo")
+	}
+}
+
+// indexSynced atualiza o índice com as rows sincronizadas. Os imóveis só são indexados depois da atualização dos
+// imóveis ativos (as rows são reinseridas como inativas), então os seus ids ficam guardados até lá.
+func (j JSync) indexSynced(tx *sql.Tx, r resource, pks []int, removed []int) {
+	if _, ok := j.searchIndexResource(r.name); !ok {
+		return
+	}
+
+	if j.config.CmdCfg.Truncate {
+		index := j.searchIndexName(r)
+		if err := j.searchIndex.Clear(index); err != nil {
+			j.L.Error().Err(err).Str("index", index).Msg("falha ao limpar o índice de busca")
+		}
+	}
+
+	if r.name == config.ResourceProperties {
+		j.searchState.changed = append(j.searchState.changed, pks...)
+		return
+	}
+
+	j.updateSearchIndex(tx, r, pks, removed)
+}
+
+// indexActiveProperties atualiza o índice de imóveis após a atualização dos imóveis ativos: os imóveis sincronizados
+// ou que passaram a ser ativos são enviados e os sincronizados ou que deixaram de ser ativos são removidos.
+func (j JSync) indexActiveProperties(tx *sql.Tx, previous map[int]bool) error {
+	current, err := j.activeIds(tx)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[int]bool, len(j.searchState.changed))
+	for _, id := range j.searchState.changed {
+		changed[id] = true
+	}
+	j.searchState.changed = nil
+
+	var upserted, removed []int
+	for id := range current {
+		if !previous[id] || changed[id] {
+			upserted = append(upserted, id)
+		}
+	}
+
+	for id := range previous {
+		if !current[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	for id := range changed {
+		if !current[id] && !previous[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	sort.Ints(upserted)
+	sort.Ints(removed)
+	j.updateSearchIndex(tx, j.resource(config.ResourceProperties), upserted, removed)
+
+	return nil
+}
+
+// activeIds retorna os ids dos imóveis ativos do tenant atual.
+func (j JSync) activeIds(q queryer) (map[int]bool, error) {
+	query, _, err := goqu.From(j.GetPropertiesTable()).
+		Select(goqu.C("id")).
+		Where(append(j.tenantCondition(), goqu.C("active").Eq(true))...).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// Reindex reconstrói os índices de busca dos recursos a partir do banco, removendo os documentos existentes. Se nenhum
+// recurso for informado, todos os recursos indexados são reconstruídos.
+func (j JSync) Reindex(resources ...string) error {
+	if j.searchIndex == nil {
+		return errors.New("a indexação em motor de busca não está habilitada (search_index.enabled)")
+	}
+
+	for _, r := range j.filterResources(resources...) {
+		if _, ok := j.searchIndexResource(r.name); !ok {
+			if len(resources) > 0 {
+				return errors.New(fmt.Sprintf(`o recurso "%s" não está configurado em search_index.resources`, r.name))
+			}
+
+			continue
+		}
+
+		index := j.searchIndexName(r)
+		docs, err := j.searchDocuments(j.db, r, nil)
+		if err != nil {
+			return err
+		}
+
+		if err = j.searchIndex.Clear(index); err != nil {
+			return err
+		}
+
+		if err = j.searchIndex.Upsert(index, idColumn(r), docs); err != nil {
+			return err
+		}
+
+		j.L.Info().Str("index", index).Int("documents", len(docs)).Msg("índice de busca reconstruído")
+	}
+
+	return nil
+}
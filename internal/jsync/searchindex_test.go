@@ -0,0 +1,328 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	encjson "encoding/json"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/json"
+	"github.com/alanwgt/jsync/internal/model"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// Os testes de integração do índice de busca utilizam um Postgres e um Meilisearch e/ou OpenSearch locais (veja
+// docker-compose.test.yml) e são ignorados se as variáveis de ambiente abaixo não estiverem definidas.
+const (
+	envTestDatabase    = "JSYNC_TEST_DATABASE_URL"
+	envTestMeilisearch = "JSYNC_TEST_MEILISEARCH_URL"
+	envTestMeiliKey    = "JSYNC_TEST_MEILISEARCH_KEY"
+	envTestOpensearch  = "JSYNC_TEST_OPENSEARCH_URL"
+)
+
+const testSchema = "jsync_search_index_test"
+
+// testEngines retorna os motores de busca configurados nas variáveis de ambiente.
+func testEngines(t *testing.T) []config.SearchIndex {
+	if os.Getenv(envTestDatabase) == "" {
+		t.Skipf("%s não definida, ignorando os testes de integração do índice de busca", envTestDatabase)
+	}
+
+	var engines []config.SearchIndex
+	if u := os.Getenv(envTestMeilisearch); u != "" {
+		engines = append(engines, config.SearchIndex{Engine: config.SearchEngineMeilisearch, Url: u, ApiKey: os.Getenv(envTestMeiliKey)})
+	}
+
+	if u := os.Getenv(envTestOpensearch); u != "" {
+		engines = append(engines, config.SearchIndex{Engine: config.SearchEngineOpensearch, Url: u})
+	}
+
+	if len(engines) == 0 {
+		t.Skipf("%s e %s não definidas, ignorando os testes de integração do índice de busca", envTestMeilisearch, envTestOpensearch)
+	}
+
+	return engines
+}
+
+// newSearchIndexTest cria as tabelas de imóveis e condomínios num schema próprio e um JSync que as sincroniza e indexa
+// em índices exclusivos do teste, removidos ao final.
+func newSearchIndexTest(t *testing.T, si config.SearchIndex) JSync {
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	si.Enabled = true
+	si.Resources = map[string]config.SearchIndexResource{
+		config.ResourceProperties:   {Index: "jsync_test_properties_" + suffix},
+		config.ResourceCondominiums: {Index: "jsync_test_condominiums_" + suffix},
+	}
+
+	propertiesTable := testSchema + ".properties"
+	condominiumsTable := testSchema + ".condominiums"
+	cfg := &config.JetimobCfg{
+		DB:          config.DB{ConnectionString: os.Getenv(envTestDatabase)},
+		SearchIndex: si,
+		Mappings: config.Mappings{
+			PropertiesTable:   &propertiesTable,
+			CondominiumsTable: &condominiumsTable,
+			Properties:        map[string]any{"id_imovel": "id", "codigo": "identifier_code", "titulo_anuncio": "ad_title"},
+			Condominiums:      map[string]any{"id_condominio": "id", "nome": "name"},
+			Brokers:           map[string]any{"id": "id"},
+			Banners:           map[string]any{"id_banner": "id"},
+		},
+	}
+
+	j, err := New(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.SetCurrentTenant(j.GetTenants()[0])
+
+	for _, q := range []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s", testSchema),
+		fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, identifier_code TEXT NOT NULL, ad_title TEXT NULL, active BOOLEAN NOT NULL DEFAULT false)", propertiesTable),
+		fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name TEXT NOT NULL)", condominiumsTable),
+	} {
+		if _, err = j.db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() {
+		for _, r := range si.Resources {
+			deleteTestIndex(t, si, r.Index)
+		}
+
+		if _, err := j.db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", testSchema)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return *j
+}
+
+func testProperty(id int, title string) model.Property {
+	return model.Property{Id: id, IdentifierCode: fmt.Sprintf("AP%d", id), AdTitle: json.NullEmptyString(null.StringFrom(title))}
+}
+
+// syncTestProperties sincroniza os imóveis e marca como ativos os ids em active, numa única transação, como a
+// sincronização de imóveis.
+func syncTestProperties(t *testing.T, j JSync, ps []model.Property, active []int) {
+	err := j.db.ExecInTx(func(tx *sql.Tx) error {
+		if err := sync(tx, j, ps, nil, j.resource(config.ResourceProperties), j.remapPropertyRow); err != nil {
+			return err
+		}
+
+		return j.MarkPropertiesAsActive(tx, active)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchIndexActiveProperties(t *testing.T) {
+	for _, si := range testEngines(t) {
+		t.Run(si.Engine, func(t *testing.T) {
+			j := newSearchIndexTest(t, si)
+			index := j.searchIndexName(j.resource(config.ResourceProperties))
+
+			syncTestProperties(t, j, []model.Property{
+				testProperty(1, "Casa"),
+				testProperty(2, "Apartamento"),
+				testProperty(3, "Terreno"),
+			}, []int{1, 2})
+			assertIndexed(t, si, index, map[int]string{1: "Casa", 2: "Apartamento"})
+
+			// 1 deixa de ser ativo, 3 passa a ser ativo e 2 é atualizado
+			syncTestProperties(t, j, []model.Property{testProperty(2, "Apartamento reformado")}, []int{2, 3})
+			assertIndexed(t, si, index, map[int]string{2: "Apartamento reformado", 3: "Terreno"})
+
+			// imóveis sincronizados e inativos não são indexados
+			syncTestProperties(t, j, []model.Property{testProperty(4, "Sala")}, []int{2, 3})
+			assertIndexed(t, si, index, map[int]string{2: "Apartamento reformado", 3: "Terreno"})
+		})
+	}
+}
+
+func TestSearchIndexReindex(t *testing.T) {
+	for _, si := range testEngines(t) {
+		t.Run(si.Engine, func(t *testing.T) {
+			j := newSearchIndexTest(t, si)
+			index := j.searchIndexName(j.resource(config.ResourceProperties))
+
+			syncTestProperties(t, j, []model.Property{testProperty(1, "Casa"), testProperty(2, "Apartamento")}, []int{1, 2})
+
+			// documentos que não estão no banco são removidos e os alterados fora da sincronização são reconstruídos
+			if err := j.searchIndex.Upsert(index, "id", []map[string]any{{"id": 99, "ad_title": "Órfão"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := j.db.Exec(fmt.Sprintf("UPDATE %s.properties SET ad_title = 'Casa com piscina' WHERE id = 1", testSchema)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := j.Reindex(config.ResourceProperties); err != nil {
+				t.Fatal(err)
+			}
+
+			assertIndexed(t, si, index, map[int]string{1: "Casa com piscina", 2: "Apartamento"})
+		})
+	}
+}
+
+func TestSearchIndexRemovedCondominiums(t *testing.T) {
+	for _, si := range testEngines(t) {
+		t.Run(si.Engine, func(t *testing.T) {
+			j := newSearchIndexTest(t, si)
+			r := j.resource(config.ResourceCondominiums)
+			index := j.searchIndexName(r)
+
+			syncCondominiums := func(cs []model.Condominium, removed []int) {
+				err := j.db.ExecInTx(func(tx *sql.Tx) error {
+					return sync(tx, j, cs, removed, r, nil)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			syncCondominiums([]model.Condominium{{Id: 10, Name: "Solar"}, {Id: 11, Name: "Jardins"}, {Id: 12, Name: "Parque"}}, nil)
+			assertIndexed(t, si, index, map[int]string{10: "Solar", 11: "Jardins", 12: "Parque"})
+
+			// condomínios descartados pelos filtros são removidos do banco e do índice
+			syncCondominiums([]model.Condominium{{Id: 10, Name: "Solar das Flores"}}, []int{11, 12})
+			assertIndexed(t, si, index, map[int]string{10: "Solar das Flores"})
+		})
+	}
+}
+
+// assertIndexed compara os documentos do índice (id e título, ou nome nos condomínios) com want.
+func assertIndexed(t *testing.T, si config.SearchIndex, index string, want map[int]string) {
+	t.Helper()
+
+	got := make(map[int]string)
+	for _, d := range indexedTestDocuments(t, si, index) {
+		id, _ := d["id"].(float64)
+		title, ok := d["ad_title"].(string)
+		if !ok {
+			title, _ = d["name"].(string)
+		}
+
+		got[int(id)] = title
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("documentos do índice %s: esperado %v, obtido %v", index, want, got)
+	}
+}
+
+// indexedTestDocuments lê todos os documentos do índice diretamente da API do motor de busca.
+func indexedTestDocuments(t *testing.T, si config.SearchIndex, index string) []map[string]any {
+	t.Helper()
+
+	base := strings.TrimSuffix(si.Url, "/")
+	switch si.Engine {
+	case config.SearchEngineMeilisearch:
+		var res struct {
+			Results []map[string]any `json:"results"`
+		}
+		testEngineRequest(t, si, http.MethodGet, fmt.Sprintf("%s/indexes/%s/documents?limit=1000", base, url.PathEscape(index)), &res)
+		return res.Results
+	case config.SearchEngineOpensearch:
+		testEngineRequest(t, si, http.MethodPost, fmt.Sprintf("%s/%s/_refresh", base, url.PathEscape(index)), nil)
+
+		var res struct {
+			Hits struct {
+				Hits []struct {
+					Source map[string]any `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		testEngineRequest(t, si, http.MethodGet, fmt.Sprintf("%s/%s/_search?size=1000", base, url.PathEscape(index)), &res)
+
+		docs := make([]map[string]any, len(res.Hits.Hits))
+		for i, h := range res.Hits.Hits {
+			docs[i] = h.Source
+		}
+
+		sort.Slice(docs, func(a, b int) bool { return fmt.Sprint(docs[a]["id"]) < fmt.Sprint(docs[b]["id"]) })
+		return docs
+	}
+
+	t.Fatalf("motor de busca %s desconhecido", si.Engine)
+	return nil
+}
+
+func deleteTestIndex(t *testing.T, si config.SearchIndex, index string) {
+	base := strings.TrimSuffix(si.Url, "/")
+	path := fmt.Sprintf("%s/%s", base, url.PathEscape(index))
+	if si.Engine == config.SearchEngineMeilisearch {
+		path = fmt.Sprintf("%s/indexes/%s", base, url.PathEscape(index))
+	}
+
+	testEngineRequest(t, si, http.MethodDelete, path, nil)
+}
+
+// testEngineRequest executa a requisição no motor de busca. Índices inexistentes resultam em dst vazio.
+func testEngineRequest(t *testing.T, si config.SearchIndex, method, u string, dst any) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if si.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+si.ApiKey)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		t.Fatalf("%s %s respondeu com status code %d", method, u, res.StatusCode)
+	}
+
+	if dst != nil {
+		if err = encjson.NewDecoder(res.Body).Decode(dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// seasonCalendarTable retorna a tabela filha do calendário de temporada dos imóveis, se configurada.
+func (j JSync) seasonCalendarTable(resource string) (childTable, bool) {
+	if resource != config.ResourceProperties || j.mappings.SeasonCalendarTable == nil || *j.mappings.SeasonCalendarTable == "" {
+		return childTable{}, false
+	}
+
+	return childTable{
+		table:  *j.mappings.SeasonCalendarTable,
+		parent: "property_id",
+		columns: []ddlColumn{
+			{"name", "TEXT NULL"},
+			{"start_date", "DATE NOT NULL"},
+			{"end_date", "DATE NOT NULL"},
+			{"daily_rate", "NUMERIC NULL"},
+			{"minimum_days", "INT NULL"},
+		},
+		rows: seasonCalendarRows,
+	}, true
+}
+
+// seasonCalendarRows converte as entradas do calendário de temporada do imóvel. As entradas sem início ou fim são
+// ignoradas.
+func seasonCalendarRows(l zerolog.Logger, v model.Model) []map[any]any {
+	p := v.(model.Property)
+
+	var rows []map[any]any
+	for _, e := range p.SeasonCalendar {
+		if e.StartDate.IsZero() || e.EndDate.IsZero() {
+			l.Warn().Int("id", p.Id).Str("name", e.Name.String).Msg("entrada do calendário de temporada sem início ou fim, ignorando")
+			continue
+		}
+
+		rows = append(rows, map[any]any{
+			"name":         e.Name,
+			"start_date":   calendarDate(e.StartDate.Time),
+			"end_date":     calendarDate(e.EndDate.Time),
+			"daily_rate":   e.DailyRate,
+			"minimum_days": e.MinimumDailyRental,
+		})
+	}
+
+	return rows
+}
+
+func calendarDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
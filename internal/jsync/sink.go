@@ -0,0 +1,277 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/db"
+	"github.com/alanwgt/jsync/internal/sink"
+)
+
+// postgresSink escreve as rows nas tabelas do banco de dados e mantém o índice de busca atualizado. Se a transação for
+// fornecida na criação, ela pertence a quem chamou e Begin, Commit e Rollback não fazem nada.
+type postgresSink struct {
+	j     JSync
+	tx    *sql.Tx
+	owned bool // a transação foi aberta pelo sink
+}
+
+func (s *postgresSink) Begin() error {
+	if s.tx != nil {
+		return nil
+	}
+
+	if !s.j.db.Configured() {
+		return db.ErrNotConfigured
+	}
+
+	tx, err := s.j.db.Connection().Begin()
+	if err != nil {
+		return err
+	}
+
+	s.tx = tx
+	s.owned = true
+	return nil
+}
+
+func (s *postgresSink) Upsert(r sink.Resource, ids []int, rows []sink.Row) error {
+	l := s.j.L.With().Str("table", r.Table).Logger()
+	inserts := make([]map[any]any, len(rows))
+	for i, row := range rows {
+		inserts[i] = make(map[any]any, len(row))
+		for k, v := range row {
+			inserts[i][k] = v
+		}
+	}
+
+	if err := s.j.writeRows(s.tx, l, r.Table, inserts, ids); err != nil {
+		return err
+	}
+
+	s.j.indexSynced(s.tx, s.j.resource(r.Name), ids, nil)
+	return nil
+}
+
+func (s *postgresSink) Delete(r sink.Resource, ids []int) error {
+	l := s.j.L.With().Str("table", r.Table).Logger()
+	if err := s.j.deleteIds(s.tx, l, r.Table, ids); err != nil {
+		return err
+	}
+
+	l.Info().Int("rows", len(ids)).Msg("rows removidas da tabela")
+	s.j.indexSynced(s.tx, s.j.resource(r.Name), nil, ids)
+	return nil
+}
+
+func (s *postgresSink) MarkActive(_ sink.Resource, ids []int) error {
+	return s.j.MarkPropertiesAsActive(s.tx, ids)
+}
+
+func (s *postgresSink) Commit() error {
+	if !s.owned {
+		return nil
+	}
+
+	return s.tx.Commit()
+}
+
+func (s *postgresSink) Rollback() error {
+	if !s.owned {
+		return nil
+	}
+
+	return s.tx.Rollback()
+}
+
+// sinkConfigs retorna os sinks configurados para o recurso. Se nenhum for configurado, o recurso é escrito apenas no
+// banco de dados.
+func (j JSync) sinkConfigs(resource string) []config.Sink {
+	if cfgs := j.config.Sinks[resource]; len(cfgs) > 0 {
+		return cfgs
+	}
+
+	return []config.Sink{{Type: config.SinkPostgres}}
+}
+
+// DatabaseResources retorna os recursos fornecidos (todos, se nenhum for fornecido) que são escritos no banco de dados.
+func (j JSync) DatabaseResources(resources ...string) []string {
+	var names []string
+	for _, r := range j.filterResources(resources...) {
+		if j.writesToDatabase(r.name) {
+			names = append(names, r.name)
+		}
+	}
+
+	return names
+}
+
+func (j JSync) writesToDatabase(resource string) bool {
+	for _, c := range j.sinkConfigs(resource) {
+		if c.Type == config.SinkPostgres {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (j JSync) validateSinks() error {
+	for resource, cfgs := range j.config.Sinks {
+		if !isResource(resource) {
+			return errors.New(fmt.Sprintf(`sinks: recurso "%s" desconhecido`, resource))
+		}
+
+		databases := 0
+		for _, c := range cfgs {
+			if c.Type == config.SinkPostgres {
+				databases++
+				continue
+			}
+
+			if _, err := sink.New(c); err != nil {
+				return errors.New(fmt.Sprintf("sinks.%s: %s", resource, err))
+			}
+		}
+
+		if databases > 1 {
+			return errors.New(fmt.Sprintf(`sinks.%s: o sink "%s" foi configurado mais de uma vez`, resource, config.SinkPostgres))
+		}
+
+		if _, ok := j.searchIndexResource(resource); ok && databases == 0 {
+			return errors.New(fmt.Sprintf(`sinks.%s: o índice de busca é atualizado a partir do banco de dados e requer o sink "%s"`, resource, config.SinkPostgres))
+		}
+	}
+
+	return nil
+}
+
+// sinks cria os sinks do recurso, na ordem configurada. tx é a transação utilizada pelo sink postgres; se nil, o sink
+// abre e confirma a sua própria transação.
+func (j JSync) sinks(tx *sql.Tx, resource string) ([]sink.Sink, error) {
+	var sinks []sink.Sink
+	for _, c := range j.sinkConfigs(resource) {
+		if c.Type == config.SinkPostgres {
+			sinks = append(sinks, &postgresSink{j: j, tx: tx})
+			continue
+		}
+
+		s, err := sink.New(c)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	return sinks, nil
+}
+
+// sinkResource descreve o recurso para os sinks, no tenant atual.
+func (j JSync) sinkResource(r resource) sink.Resource {
+	sr := sink.Resource{
+		Name:     r.name,
+		Table:    r.table,
+		IdColumn: idColumn(r),
+		Truncate: j.config.CmdCfg.Truncate,
+	}
+
+	if j.multiTenant {
+		sr.Tenant = j.currentTenant.Identifier
+	}
+
+	return sr
+}
+
+// deferredSinks guarda os sinks cuja confirmação aguarda o commit da transação do banco de dados, para que eventos e
+// arquivos não sejam publicados para dados revertidos.
+type deferredSinks struct {
+	sinks []sink.Sink
+}
+
+// commitDeferredSinks confirma os sinks adiados, na ordem em que foram escritos. Se a confirmação de um sink falhar,
+// os sinks seguintes são revertidos.
+func (j JSync) commitDeferredSinks() error {
+	sinks := j.deferred.sinks
+	j.deferred.sinks = nil
+	for i, s := range sinks {
+		if err := s.Commit(); err != nil {
+			j.rollbackSinks(sinks[i+1:])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSinks executa f em cada sink do recurso e, se nenhum falhar, confirma os sinks na ordem configurada. Se a
+// confirmação de um sink falhar, os sinks seguintes são revertidos. Dentro da transação de SyncAll, a confirmação dos
+// sinks que não são o banco de dados é adiada até o commit da transação.
+func (j JSync) writeSinks(tx *sql.Tx, resource string, f func(s sink.Sink) error) error {
+	sinks, err := j.sinks(tx, resource)
+	if err != nil {
+		return err
+	}
+
+	for i, s := range sinks {
+		if err = s.Begin(); err != nil {
+			j.rollbackSinks(sinks[:i])
+			return err
+		}
+	}
+
+	for _, s := range sinks {
+		if err = f(s); err != nil {
+			j.rollbackSinks(sinks)
+			return err
+		}
+	}
+
+	for i, s := range sinks {
+		if _, database := s.(*postgresSink); !database && tx != nil && j.deferred != nil {
+			j.deferred.sinks = append(j.deferred.sinks, s)
+			continue
+		}
+
+		if err = s.Commit(); err != nil {
+			j.rollbackSinks(sinks[i+1:])
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j JSync) rollbackSinks(sinks []sink.Sink) {
+	for _, s := range sinks {
+		if err := s.Rollback(); err != nil {
+			j.L.Error().Err(err).Msg("falha ao reverter as escritas do sink")
+		}
+	}
+}
@@ -32,13 +32,14 @@ import (
 	"github.com/alanwgt/jsync/internal/config"
 	"github.com/alanwgt/jsync/internal/db"
 	"github.com/alanwgt/jsync/internal/http"
+	"github.com/alanwgt/jsync/internal/media"
 	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/searchindex"
+	"github.com/alanwgt/jsync/internal/sink"
 	"github.com/alanwgt/jsync/log"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/rs/zerolog"
 	"reflect"
-	"sort"
-	"strings"
 	"time"
 )
 
@@ -49,6 +50,12 @@ type JSync struct {
 	db            *db.Db
 	multiTenant   bool
 	currentTenant *config.TenantMapping
+	mappings      config.Mappings     // mapeamento global mesclado com o do tenant atual
+	mirror        *media.Mirror       // nil se o espelhamento de mídias estiver desabilitado
+	searchIndex   *searchindex.Client // nil se a indexação em motor de busca estiver desabilitada
+	searchState   *searchIndexState
+	deferred      *deferredSinks // sinks confirmados apenas após o commit da transação de SyncAll
+	baseL         zerolog.Logger
 	L             zerolog.Logger
 }
 
@@ -58,28 +65,71 @@ func New(cfg *config.JetimobCfg, version string) (*JSync, error) {
 		return nil, err
 	}
 
-	return &JSync{
+	l := log.Log.With().Str("version", version).Logger()
+	j := &JSync{
 		config:      cfg,
 		requester:   http.NewRequester(cfg.CmdCfg.MaxPages, cfg.CmdCfg.ConcurrentRequests),
 		db:          d,
 		multiTenant: len(cfg.TenantMapping) > 0,
-		L:           log.Log.With().Str("version", version).Logger(),
-	}, nil
+		mappings:    cfg.Mappings,
+		baseL:       l,
+		L:           l,
+	}
+
+	if err := j.validateMappings(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Media.Enabled {
+		m, err := media.NewMirror(cfg.Media)
+		if err != nil {
+			return nil, err
+		}
+
+		j.mirror = m
+	}
+
+	if cfg.SearchIndex.Enabled {
+		c, err := searchindex.New(cfg.SearchIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		j.searchIndex = c
+		j.searchState = &searchIndexState{}
+	}
+
+	if err := j.validateSinks(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
 }
 
 func (j *JSync) SetCurrentTenant(t config.TenantMapping) {
-	if j.multiTenant && t.Identifier == "" || t.WebserviceKey == "" {
+	if j.multiTenant && (t.Identifier == "" || t.WebserviceKey == "") {
 		j.L.Panic().Str("identifier", t.Identifier).Str("webservice_key", t.WebserviceKey).Msg("falha de configuração em ambiente multi tenancy")
 	}
 
 	j.currentTenant = &t
+	j.mappings = j.config.Mappings.Merge(t.Mappings)
 	j.requester.SetWebserviceKey(t.WebserviceKey)
 
 	if j.multiTenant {
-		j.L = j.L.With().Str("tenant", t.Identifier).Logger()
+		j.L = j.baseL.With().Str("tenant", t.Identifier).Logger()
 	}
 }
 
+// resourceEnabled indica se o recurso deve ser sincronizado para o tenant atual.
+func (j JSync) resourceEnabled(resource string) bool {
+	if j.currentTenant != nil && !j.currentTenant.ResourceEnabled(resource) {
+		j.L.Info().Str("resource", resource).Msg("recurso desabilitado para o tenant, pulando")
+		return false
+	}
+
+	return true
+}
+
 func (j JSync) Config() *config.JetimobCfg {
 	return j.config
 }
@@ -92,6 +142,15 @@ func (j JSync) Db() *db.Db {
 	return j.db
 }
 
+// FeedConfig retorna os dados do anunciante dos feeds, considerando a configuração do tenant atual.
+func (j JSync) FeedConfig() config.Feed {
+	if j.currentTenant == nil {
+		return j.config.Feed
+	}
+
+	return j.config.Feed.Merge(j.currentTenant.Feed)
+}
+
 func getDefaultTableName(opt *string, def string) string {
 	if opt == nil {
 		return def
@@ -101,26 +160,32 @@ func getDefaultTableName(opt *string, def string) string {
 }
 
 func (j JSync) GetPropertiesTable() string {
-	return getDefaultTableName(j.config.Mappings.PropertiesTable, config.DefaultPropertiesTable)
+	return getDefaultTableName(j.mappings.PropertiesTable, config.DefaultPropertiesTable)
 }
 
 func (j JSync) GetCondominiumsTable() string {
-	return getDefaultTableName(j.config.Mappings.CondominiumsTable, config.DefaultCondominiumsTable)
+	return getDefaultTableName(j.mappings.CondominiumsTable, config.DefaultCondominiumsTable)
 }
 
 func (j JSync) GetBannersTable() string {
-	return getDefaultTableName(j.config.Mappings.BannersTable, config.DefaultBannersTable)
+	return getDefaultTableName(j.mappings.BannersTable, config.DefaultBannersTable)
 }
 
 func (j JSync) GetBrokersTable() string {
-	return getDefaultTableName(j.config.Mappings.BrokersTable, config.DefaultBrokersTable)
+	return getDefaultTableName(j.mappings.BrokersTable, config.DefaultBrokersTable)
 }
 
 func (j JSync) GetTenants() []config.TenantMapping {
 	if !j.multiTenant {
+		// a chave pode estar vazia em comandos que não acessam a Jetimob, como a exportação a partir do banco
+		var key string
+		if j.config.WebserviceKey != nil {
+			key = *j.config.WebserviceKey
+		}
+
 		return []config.TenantMapping{{
 			Identifier:    "",
-			WebserviceKey: *j.config.WebserviceKey,
+			WebserviceKey: key,
 		}}
 	}
 
@@ -129,10 +194,7 @@ func (j JSync) GetTenants() []config.TenantMapping {
 		for _, t := range j.config.TenantMapping {
 			if t.Identifier == j.config.CmdCfg.TenantId {
 				j.L.Debug().Str("tenant", j.config.CmdCfg.TenantId).Msg("tenant encontrado")
-				return []config.TenantMapping{{
-					Identifier:    t.Identifier,
-					WebserviceKey: t.WebserviceKey,
-				}}
+				return []config.TenantMapping{t}
 			}
 		}
 
@@ -142,124 +204,242 @@ func (j JSync) GetTenants() []config.TenantMapping {
 	return j.config.TenantMapping
 }
 
-func sync[T model.Model](tx *sql.Tx, j JSync, values []T, colMap map[string]any, table string, beforeInsert BeforeInsertCallback) error {
-	l := j.L.With().Str("table", table).Logger()
+// sync escreve os valores nos sinks do recurso, substituindo os itens existentes com os mesmos ids. Os itens com os ids
+// em removed (descartados pelos filtros) são removidos. Se tx for nil, o sink postgres utiliza uma transação própria.
+func sync[T model.Model](tx *sql.Tx, j JSync, values []T, removed []int, r resource, beforeInsert BeforeInsertCallback) error {
+	l := j.L.With().Str("table", r.table).Logger()
 	l.Debug().Msg("iniciando sincronização de dados")
 
-	if len(values) == 0 {
-		return nil
-	}
-
-	tagMap := extractTagMap(values[0])
-	pks := make([]int, len(values))
-	cols, i := make([]string, len(tagMap)), 0
+	var pks []int
+	var rows []sink.Row
+	if len(values) > 0 {
+		inserts, ids, err := mappedRows(j, l, values, r, beforeInsert)
+		if err != nil {
+			return err
+		}
 
-	for k := range tagMap {
-		cols[i] = fmt.Sprintf(`"%s"`, k)
-		i++
+		pks = ids
+		rows = make([]sink.Row, len(inserts))
+		for i, m := range inserts {
+			rows[i] = make(sink.Row, len(m))
+			for k, v := range m {
+				rows[i][k.(string)] = v
+			}
+		}
 	}
 
-	sort.Strings(cols)
-
-	var inserts []map[any]any
-	for vi, v := range values {
-		m := make(map[any]any)
-		for _, col := range cols {
-			col = strings.Replace(col, `"`, "", 2)
-			colName, ok := tagMap[col]
-			if !ok {
-				return errors.New(fmt.Sprintf(`não há propriedade "%s" para a tabela "%s"`, col, table))
+	children := mappedChildRows(j, values, r)
+	sr := j.sinkResource(r)
+	return j.writeSinks(tx, r.name, func(s sink.Sink) error {
+		// as tabelas filhas existem apenas no banco de dados
+		ps, database := s.(*postgresSink)
+		if database && (len(pks) > 0 || len(removed) > 0) {
+			if err := j.deleteChildren(ps.tx, children, pks, removed); err != nil {
+				return err
 			}
+		}
 
-			colSplit := strings.Split(col, ".")
-			col = colSplit[len(colSplit)-1]
-
-			remappedCol, ok := colMap[col]
-			if !ok {
-				l.Info().Str("column", col).Msg("remapeamento não especificado para coluna, pulando")
-				continue
+		if len(removed) > 0 && !sr.Truncate {
+			if err := s.Delete(sr, removed); err != nil {
+				return err
 			}
+		}
 
-			m[remappedCol] = reflect.ValueOf(v).FieldByName(colName.Name()).Interface()
+		if len(rows) == 0 {
+			return nil
 		}
 
-		if j.multiTenant {
-			m[*j.config.TenantDiscriminatorColumn] = j.currentTenant.Identifier
+		if err := s.Upsert(sr, pks, rows); err != nil {
+			return err
 		}
 
-		pks[vi] = v.Identifier()
+		if database {
+			return j.writeChildren(ps.tx, children, pks)
+		}
 
-		if beforeInsert != nil {
-			m = beforeInsert(m)
+		return nil
+	})
+}
+
+// writeRows substitui na tabela as rows com os ids em pks pelas rows fornecidas, utilizando COPY quando configurado.
+func (j JSync) writeRows(tx *sql.Tx, l zerolog.Logger, table string, inserts []map[any]any, pks []int) error {
+	if j.config.DB.Copy {
+		err := j.copyRows(tx, l, table, inserts)
+		if err == nil {
+			l.Info().Int("rows", len(inserts)).Msg("dados sincronizados")
+			return nil
 		}
 
-		inserts = append(inserts, m)
+		if !errors.Is(err, errCopyUnsupported) {
+			l.Error().Err(err).Msg("falha ao inserir dados no banco")
+			return err
+		}
+
+		l.Warn().Err(err).Msg("COPY indisponível para a tabela, utilizando INSERT")
 	}
 
 	if j.config.CmdCfg.Truncate {
 		l.Warn().Bool("truncate", true).Msg("truncando tabela")
-		exp := goqu.Delete(table)
-		if j.multiTenant {
-			exp = exp.Where(goqu.C(*j.config.TenantDiscriminatorColumn).Eq(j.currentTenant.Identifier))
-		}
-		q, _, err := exp.ToSQL()
-		if err != nil {
+		if err := j.clearTable(tx, table, j.currentTenant.Identifier); err != nil {
 			return err
 		}
 
-		_, err = tx.Exec(q)
-		if err != nil {
+		l.Info().Msg("tabela truncada")
+	} else {
+		if err := j.deleteIds(tx, l, table, pks); err != nil {
 			return err
 		}
 
-		l.Info().Msg("tabela truncada")
-	} else {
-		exp := goqu.Delete(table)
+		l.Info().Int("rows", len(pks)).Msg("rows desatualizadas removidas da tabela")
+	}
+
+	if err := j.insertRows(tx, l, table, inserts); err != nil {
+		l.Error().Err(err).Msg("falha ao inserir dados no banco")
+		return err
+	}
+
+	l.Info().Int("rows", len(inserts)).Msg("dados sincronizados")
+	return nil
+}
+
+// mappedRows converte os itens nas rows da tabela do recurso, aplicando o mapeamento de colunas, as colunas calculadas e
+// derivadas, a coluna de tenant, as transformações e o beforeInsert. Os ids dos itens são retornados na mesma ordem.
+func mappedRows[T model.Model](j JSync, l zerolog.Logger, values []T, r resource, beforeInsert BeforeInsertCallback) ([]map[any]any, []int, error) {
+	fields, unmapped, err := j.writtenFields(r, values[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, key := range unmapped {
+		l.Info().Str("column", key).Msg("remapeamento não especificado para coluna, pulando")
+	}
+
+	transforms, err := transformCallback(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	computed, err := computedColumns(r.mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	needsTemplateData := hasTemplate(computed)
+	geoColumn, hasGeography := j.geographyColumn(r.name)
+	searchColumn, hasSearch := j.searchColumn(r.name)
+
+	var searchFs []searchField
+	if hasSearch {
+		if searchFs, err = j.searchFields(values[0]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, public := j.publicMode(r)
+
+	pks := make([]int, len(values))
+	var inserts []map[any]any
+	for vi, v := range values {
+		// no modo público, as colunas, os templates e a busca textual são calculados sem os valores ocultos
+		item, hidden := v, map[string]bool(nil)
+		if public {
+			item, hidden = publicItem(j, v)
+		}
+
+		m := make(map[any]any)
+		rv, pv := reflect.ValueOf(v), reflect.ValueOf(item)
+		for _, f := range fields {
+			switch {
+			case f.private:
+				m[f.column] = rv.FieldByName(f.prop.Name()).Interface()
+			case hidden[f.key]:
+				m[f.column] = nil
+			default:
+				m[f.column] = pv.FieldByName(f.prop.Name()).Interface()
+			}
+		}
+
 		if j.multiTenant {
-			exp = exp.Where(goqu.C(*j.config.TenantDiscriminatorColumn).Eq(j.currentTenant.Identifier))
+			m[j.GetTenantColumn()] = j.currentTenant.Identifier
 		}
 
-		q, _, err := exp.
-			Where(goqu.C("id").In(pks)).
-			ToSQL()
-		if err != nil {
-			return err
+		var data map[string]any
+		if needsTemplateData {
+			data = templateData(item, j.currentTenant.Identifier)
 		}
 
-		_, err = tx.Exec(q)
-		if err != nil {
-			return err
+		for _, c := range computed {
+			if m[c.mapping.Column], err = c.eval(data); err != nil {
+				return nil, nil, fmt.Errorf(`falha ao calcular a coluna "%s" do item %d: %w`, c.mapping.Column, v.Identifier(), err)
+			}
+		}
+
+		if hasGeography {
+			m[geoColumn] = j.geographyValue(v)
+		}
+
+		if hasSearch {
+			m[searchColumn] = j.searchValue(searchFs, item)
 		}
 
-		l.Info().Ints("ids", pks).Msg("rows desatualizadas removidas da tabela")
+		pks[vi] = v.Identifier()
+
+		if beforeInsert != nil {
+			m = beforeInsert(m)
+		}
+
+		if transforms != nil {
+			if err = transforms(m); err != nil {
+				return nil, nil, fmt.Errorf("item %d: %w", v.Identifier(), err)
+			}
+		}
+
+		inserts = append(inserts, m)
 	}
 
-	q, _, err := goqu.
-		Dialect("postgres").
-		Insert(table).
-		Rows(inserts).
-		ToSQL()
+	return inserts, pks, nil
+}
 
-	if err != nil {
-		return err
+// withoutExcluded remove de ids os imóveis em excluded, que são marcados como inativos.
+func (j JSync) withoutExcluded(ids []int, excluded []int) []int {
+	if len(excluded) == 0 {
+		return ids
 	}
 
-	_, err = tx.Exec(q)
+	skip := make(map[int]bool, len(excluded))
+	for _, id := range excluded {
+		skip[id] = true
+	}
 
-	if err != nil {
-		l.Error().Err(err).Msg("falha ao inserir dados no banco")
-	} else {
-		l.Info().Int("rows", len(inserts)).Str("table", table).Msg("dados sincronizados")
+	var active []int
+	for _, id := range ids {
+		if !skip[id] {
+			active = append(active, id)
+		}
 	}
 
-	return err
+	j.L.Info().Int("excluded", len(ids)-len(active)).Msg("imóveis filtrados serão marcados como inativos")
+	return active
 }
 
-func (j JSync) MarkPropertiesAsActive(tx *sql.Tx, ids []int) error {
+// MarkPropertiesAsActive marca como ativos apenas os imóveis com os ids fornecidos, com exceção dos ids em excluded
+// (imóveis descartados pelos filtros).
+func (j JSync) MarkPropertiesAsActive(tx *sql.Tx, ids []int, excluded ...int) error {
+	ids = j.withoutExcluded(ids, excluded)
+
+	// estado anterior, utilizado para atualizar o índice de busca apenas com os imóveis que mudaram
+	var previous map[int]bool
+	_, indexed := j.searchIndexResource(config.ResourceProperties)
+	if indexed {
+		var err error
+		if previous, err = j.activeIds(tx); err != nil {
+			return err
+		}
+	}
+
 	table := j.GetPropertiesTable()
 	exp := goqu.Update(table)
 	if j.multiTenant {
-		exp = exp.Where(goqu.C(*j.config.TenantDiscriminatorColumn).Eq(j.currentTenant.Identifier))
+		exp = exp.Where(goqu.C(j.GetTenantColumn()).Eq(j.currentTenant.Identifier))
 	}
 
 	q, _, err := exp.
@@ -277,7 +457,7 @@ func (j JSync) MarkPropertiesAsActive(tx *sql.Tx, ids []int) error {
 
 	exp = goqu.Update(table)
 	if j.multiTenant {
-		exp = exp.Where(goqu.C(*j.config.TenantDiscriminatorColumn).Eq(j.currentTenant.Identifier))
+		exp = exp.Where(goqu.C(j.GetTenantColumn()).Eq(j.currentTenant.Identifier))
 	}
 
 	q, _, err = exp.
@@ -293,47 +473,64 @@ func (j JSync) MarkPropertiesAsActive(tx *sql.Tx, ids []int) error {
 		return err
 	}
 
+	if indexed {
+		return j.indexActiveProperties(tx, previous)
+	}
+
 	return nil
 }
 
+// SyncAll sincroniza todos os recursos numa única transação do banco de dados. Os demais sinks só são confirmados
+// após o commit da transação e são revertidos se ele falhar. Sem banco de dados configurado, cada recurso é confirmado
+// nos seus sinks ao final da sua sincronização.
 func (j JSync) SyncAll() error {
-	return j.Db().ExecInTx(func(tx *sql.Tx) error {
-		if err := j.SyncBanners(tx); err != nil {
-			return err
-		}
+	if !j.db.Configured() {
+		return j.syncAll(nil)
+	}
 
-		if err := j.SyncBrokers(tx); err != nil {
-			return err
-		}
+	j.deferred = &deferredSinks{}
+	if err := j.Db().ExecInTx(j.syncAll); err != nil {
+		j.rollbackSinks(j.deferred.sinks)
+		return err
+	}
 
-		if err := j.SyncCondominiums(tx); err != nil {
-			return err
-		}
+	return j.commitDeferredSinks()
+}
 
-		if err := j.SyncProperties(tx); err != nil {
-			return err
-		}
+func (j JSync) syncAll(tx *sql.Tx) error {
+	if err := j.SyncBanners(tx); err != nil {
+		return err
+	}
 
-		return nil
-	})
-}
+	if err := j.SyncBrokers(tx); err != nil {
+		return err
+	}
 
-func syncSingle[T model.Model](tx *sql.Tx, j JSync, vs []T, colMap map[string]any, table string, beforeInsert BeforeInsertCallback) error {
-	if tx != nil {
-		return sync(tx, j, vs, colMap, table, beforeInsert)
+	if err := j.SyncCondominiums(tx); err != nil {
+		return err
 	}
 
-	return j.Db().ExecInTx(func(tx *sql.Tx) error {
-		return sync(tx, j, vs, colMap, table, beforeInsert)
-	})
+	if err := j.SyncProperties(tx); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func (j JSync) SyncProperties(tx *sql.Tx) error {
+	if !j.resourceEnabled(config.ResourceProperties) {
+		return nil
+	}
+
 	j.L.Info().Msg("iniciando sincronização de imóveis")
 	var lastSync *time.Time
 
 	if j.config.CmdCfg.IgnoreLastSync {
 		j.L.Debug().Msg("ignorando última data de sincronização, requisitando todos os imóveis")
+	} else if len(j.resourceFilters(config.ResourceProperties)) > 0 {
+		// os imóveis descartados em sincronizações anteriores também precisam ser conhecidos, senão seriam marcados
+		// como ativos novamente
+		j.L.Debug().Msg("filtros de imóveis configurados, requisitando todos os imóveis")
 	} else if j.config.LastSync != nil {
 		lastSync = j.config.LastSync
 		j.L.Debug().Time("last_sync", *lastSync).Msg("utilizando útlima data de sincronização")
@@ -344,7 +541,19 @@ func (j JSync) SyncProperties(tx *sql.Tx) error {
 		return err
 	}
 
-	if err = syncSingle(tx, j, cs, j.config.Mappings.Properties, j.GetPropertiesTable(), j.remapPropertyRow); err != nil {
+	// os imóveis filtrados não são removidos, apenas marcados como inativos
+	cs, filtered, err := filterValues(j, config.ResourceProperties, cs)
+	if err != nil {
+		return err
+	}
+
+	mirrorMedia(j, config.ResourceProperties, cs)
+
+	if err = j.checkReferences(tx, cs); err != nil {
+		return err
+	}
+
+	if err = sync(tx, j, cs, nil, j.resource(config.ResourceProperties), j.remapPropertyRow); err != nil {
 		return err
 	}
 
@@ -352,7 +561,7 @@ func (j JSync) SyncProperties(tx *sql.Tx) error {
 		j.L.Error().Err(err).Msg("falha ao salvar data de sincronização no arquivo de configuração")
 	}
 
-	if err := j.SyncActiveProperties(tx); err != nil {
+	if err := j.syncActiveProperties(tx, filtered); err != nil {
 		return err
 	}
 
@@ -360,49 +569,106 @@ func (j JSync) SyncProperties(tx *sql.Tx) error {
 }
 
 func (j JSync) SyncBrokers(tx *sql.Tx) error {
+	if !j.resourceEnabled(config.ResourceBrokers) {
+		return nil
+	}
+
 	j.L.Info().Msg("iniciando sincronização de corretores")
 	bs, err := j.requester.GetBrokers()
 	if err != nil {
 		return err
 	}
 
-	return syncSingle(tx, j, bs, j.config.Mappings.Brokers, j.GetBrokersTable(), nil)
+	bs, filtered, err := filterValues(j, config.ResourceBrokers, bs)
+	if err != nil {
+		return err
+	}
+
+	return sync(tx, j, bs, filtered, j.resource(config.ResourceBrokers), nil)
 }
 
 func (j JSync) SyncBanners(tx *sql.Tx) error {
+	if !j.resourceEnabled(config.ResourceBanners) {
+		return nil
+	}
+
 	j.L.Info().Msg("iniciando sincronização de banners")
 	vs, err := j.requester.GetBanners()
 	if err != nil {
 		return err
 	}
 
-	return syncSingle(tx, j, vs, j.config.Mappings.Banners, j.GetBannersTable(), nil)
+	vs, filtered, err := filterValues(j, config.ResourceBanners, vs)
+	if err != nil {
+		return err
+	}
+
+	mirrorMedia(j, config.ResourceBanners, vs)
+
+	return sync(tx, j, vs, filtered, j.resource(config.ResourceBanners), nil)
 }
 
 func (j JSync) SyncCondominiums(tx *sql.Tx) error {
+	if !j.resourceEnabled(config.ResourceCondominiums) {
+		return nil
+	}
+
 	j.L.Info().Msg("iniciando sincronização de condomínios")
 	cs, err := j.requester.GetCondominiums()
 	if err != nil {
 		return err
 	}
 
-	e := syncSingle(tx, j, cs, j.config.Mappings.Condominiums, j.GetCondominiumsTable(), nil)
-	return e
+	cs, filtered, err := filterValues(j, config.ResourceCondominiums, cs)
+	if err != nil {
+		return err
+	}
+
+	mirrorMedia(j, config.ResourceCondominiums, cs)
+
+	return sync(tx, j, cs, filtered, j.resource(config.ResourceCondominiums), nil)
 }
 
+// SyncActiveProperties marca como ativos os imóveis ativos na Jetimob. Se houver filtros de imóveis, todos os imóveis
+// são requisitados para que os descartados pelos filtros continuem inativos.
 func (j JSync) SyncActiveProperties(tx *sql.Tx) error {
+	if !j.resourceEnabled(config.ResourceProperties) {
+		return nil
+	}
+
+	var excluded []int
+	if len(j.resourceFilters(config.ResourceProperties)) > 0 {
+		ps, err := j.requester.GetProperties(nil)
+		if err != nil {
+			return err
+		}
+
+		if _, excluded, err = filterValues(j, config.ResourceProperties, ps); err != nil {
+			return err
+		}
+	}
+
+	return j.syncActiveProperties(tx, excluded)
+}
+
+// syncActiveProperties marca como ativos os imóveis ativos na Jetimob, com exceção dos ids em excluded.
+func (j JSync) syncActiveProperties(tx *sql.Tx, excluded []int) error {
+	if !j.resourceEnabled(config.ResourceProperties) {
+		return nil
+	}
+
 	j.L.Info().Msg("iniciando sincronização de imóveis ativos")
 	ids, err := j.requester.GetActiveProperties()
 	if err != nil {
 		return err
 	}
 
-	if tx != nil {
-		return j.MarkPropertiesAsActive(tx, ids)
-	}
+	ids = j.withoutExcluded(ids, excluded)
+	r := j.resource(config.ResourceProperties)
+	sr := j.sinkResource(r)
 
-	return j.Db().ExecInTx(func(tx *sql.Tx) error {
-		return j.MarkPropertiesAsActive(tx, ids)
+	return j.writeSinks(tx, r.name, func(s sink.Sink) error {
+		return s.MarkActive(sr, ids)
 	})
 }
 
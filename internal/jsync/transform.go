@@ -0,0 +1,196 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"github.com/alanwgt/jsync/internal/transform"
+)
+
+// transformCallback cria a função que aplica as transformações configuradas em cada coluna da row. Se uma transformação
+// falhar, o erro é retornado e a sincronização é interrompida, como nas colunas calculadas, para que um valor fora do
+// formato esperado pela coluna não seja gravado. Retorna nil se nenhum campo possuir transformações.
+func transformCallback(fields []mappedField) (func(row map[any]any) error, error) {
+	pipelines := make(map[string]transform.Func)
+	for _, f := range fields {
+		if len(f.transforms) == 0 {
+			continue
+		}
+
+		p, err := transform.Pipeline(f.transforms)
+		if err != nil {
+			return nil, fmt.Errorf(`campo "%s": %w`, f.key, err)
+		}
+
+		pipelines[f.column] = p
+	}
+
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+
+	return func(row map[any]any) error {
+		for col, p := range pipelines {
+			v, err := p(row[col])
+			if err != nil {
+				return fmt.Errorf(`falha ao transformar o valor %v da coluna "%s": %w`, row[col], col, err)
+			}
+
+			row[col] = v
+		}
+
+		return nil
+	}, nil
+}
+
+// validateMappings interpreta o mapeamento e os filtros de todos os recursos, garantindo que as colunas,
+// transformações, colunas calculadas e filtros configurados são válidos.
+func (j JSync) validateMappings() error {
+	if err := j.validateResourceMappings(); err != nil {
+		return err
+	}
+
+	if err := j.validateFilters(j.config.Filters); err != nil {
+		return err
+	}
+
+	if err := validateGeography(j.config.Geography); err != nil {
+		return err
+	}
+
+	if err := validateIntegrity(j.config.Integrity); err != nil {
+		return err
+	}
+
+	// o modo público também escreve as coordenadas aproximadas, mesmo sem a coluna geográfica
+	if p := j.config.Mappings.Public; p != nil && p.Enabled {
+		if err := validateGeographySecret(j.config.Geography); err != nil {
+			return err
+		}
+	}
+
+	if j.config.Search.Enabled {
+		if _, err := j.searchFields(model.Property{}); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range j.config.TenantMapping {
+		for _, r := range t.Resources {
+			if !isResource(r) {
+				return fmt.Errorf(`recurso "%s" desconhecido na configuração do tenant "%s"`, r, t.Identifier)
+			}
+		}
+
+		if err := j.validateFilters(t.Filters); err != nil {
+			return fmt.Errorf(`tenant "%s": %w`, t.Identifier, err)
+		}
+
+		if t.Mappings == nil {
+			continue
+		}
+
+		jt := j
+		jt.mappings = j.config.Mappings.Merge(t.Mappings)
+		if err := jt.validateResourceMappings(); err != nil {
+			return fmt.Errorf(`tenant "%s": %w`, t.Identifier, err)
+		}
+
+		if p := jt.mappings.Public; p != nil && p.Enabled {
+			if err := validateGeographySecret(j.config.Geography); err != nil {
+				return fmt.Errorf(`tenant "%s": %w`, t.Identifier, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (j JSync) validateResourceMappings() error {
+	for _, r := range j.resources() {
+		fields, _, err := j.writtenFields(r, r.model)
+		if err != nil {
+			return fmt.Errorf("mapeamento de %s: %w", r.name, err)
+		}
+
+		if _, err = transformCallback(fields); err != nil {
+			return fmt.Errorf("mapeamento de %s: %w", r.name, err)
+		}
+
+		if idColumn(r) == "" {
+			return errors.New(fmt.Sprintf("mapeamento de %s: o campo id do recurso precisa estar mapeado", r.name))
+		}
+
+		computed, err := computedColumns(r.mapping)
+		if err != nil {
+			return fmt.Errorf("mapeamento de %s: %w", r.name, err)
+		}
+
+		if err = validateTemplates(computed, r.model); err != nil {
+			return fmt.Errorf("mapeamento de %s: %w", r.name, err)
+		}
+
+		if err = validatePrivateColumns(fields, computed); err != nil {
+			return fmt.Errorf("mapeamento de %s: %w", r.name, err)
+		}
+
+		known := make(map[string]bool)
+		for _, f := range fields {
+			known[f.key] = true
+		}
+
+		for _, c := range computed {
+			known[c.key] = true
+		}
+
+		for key := range r.mapping {
+			if !known[key] {
+				j.L.Warn().Str("resource", r.name).Str("field", key).Msg("o campo mapeado não existe no recurso e não é uma coluna calculada, ignorando")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (j JSync) validateFilters(filters config.Filters) error {
+	for name, fs := range filters {
+		if !isResource(name) {
+			return fmt.Errorf(`filtros de recurso "%s" desconhecido`, name)
+		}
+
+		for _, r := range j.filterResources(name) {
+			if _, err := compileFilters(r.model, fs); err != nil {
+				return fmt.Errorf("filtros de %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package jsync
+
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/json"
+	"gopkg.in/guregu/null.v4"
+	"reflect"
+)
+
+// sqlKind agrupa os tipos dos modelos pela forma como são escritos no banco.
+type sqlKind int
+
+const (
+	kindUnknown sqlKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindText
+	kindTextArray
+	kindTime
+	kindJson
+)
+
+var kindNames = map[sqlKind]string{
+	kindUnknown:   "desconhecido",
+	kindInt:       "inteiro",
+	kindFloat:     "decimal",
+	kindBool:      "booleano",
+	kindText:      "texto",
+	kindTextArray: "vetor de texto",
+	kindTime:      "data/hora",
+	kindJson:      "json",
+}
+
+// compatibleUdts são os tipos do postgres (coluna udt_name do information_schema) aceitos por cada sqlKind.
+var compatibleUdts = map[sqlKind][]string{
+	kindInt:       {"int2", "int4", "int8", "numeric", "float4", "float8"},
+	kindFloat:     {"numeric", "float4", "float8"},
+	kindBool:      {"bool"},
+	kindText:      {"text", "varchar", "bpchar", "citext", "date", "timestamp", "timestamptz"},
+	kindTextArray: {"_text", "_varchar", "_bpchar", "_citext"},
+	kindTime:      {"timestamp", "timestamptz", "date"},
+	kindJson:      {"json", "jsonb"},
+}
+
+func (k sqlKind) String() string {
+	return kindNames[k]
+}
+
+func (k sqlKind) acceptsUdt(udt string) bool {
+	for _, u := range compatibleUdts[k] {
+		if u == udt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// kindOf retorna como o tipo t é escrito no banco e se ele pode ser nulo.
+func kindOf(t reflect.Type) (sqlKind, bool) {
+	switch t {
+	case reflect.TypeOf(null.Int{}):
+		return kindInt, true
+	case reflect.TypeOf(null.Float{}):
+		return kindFloat, true
+	case reflect.TypeOf(null.Bool{}):
+		return kindBool, true
+	case reflect.TypeOf(null.String{}), reflect.TypeOf(json.NullEmptyString{}):
+		return kindText, true
+	case reflect.TypeOf(null.Time{}):
+		return kindTime, true
+	case reflect.TypeOf(json.JTime{}):
+		return kindTime, false
+	case reflect.TypeOf(json.CommaStrSlice{}), reflect.TypeOf(json.StrSlice{}):
+		return kindTextArray, false
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return kindInt, false
+	case reflect.Float32, reflect.Float64:
+		return kindFloat, false
+	case reflect.Bool:
+		return kindBool, false
+	case reflect.String:
+		return kindText, false
+	case reflect.Slice:
+		// vetores de structs são serializados como jsonb (encoding.JsonbArray)
+		return kindJson, false
+	}
+
+	return kindUnknown, false
+}
+
+// valueKind retorna o sqlKind de um valor do arquivo de configuração.
+func valueKind(v any) sqlKind {
+	if v == nil {
+		return kindUnknown
+	}
+
+	k, _ := kindOf(reflect.TypeOf(v))
+	return k
+}
+
+// uniformKind retorna o sqlKind dos valores, se todos forem do mesmo tipo.
+func uniformKind(vs ...any) sqlKind {
+	kind := kindUnknown
+	for i, v := range vs {
+		k := valueKind(v)
+		if i > 0 && k != kind {
+			return kindUnknown
+		}
+
+		kind = k
+	}
+
+	return kind
+}
+
+// transformedKind retorna como o valor de um campo do tipo kind é escrito no banco após as transformações.
+func transformedKind(kind sqlKind, nullable bool, ts []config.Transform) (sqlKind, bool) {
+	for _, t := range ts {
+		switch t.Name {
+		case "join", "date_format":
+			kind = kindText
+		case "split":
+			kind = kindTextArray
+		case "scale":
+			kind = kindFloat
+		case "default":
+			nullable = false
+			if k := valueKind(t.Args); k != kind {
+				kind = kindUnknown
+			}
+		case "map":
+			m, _ := t.Args.(map[string]any)
+			vs := make([]any, 0, len(m))
+			for _, v := range m {
+				vs = append(vs, v)
+			}
+
+			if k := uniformKind(vs...); k != kind && kind != kindTextArray {
+				kind = kindUnknown
+			}
+		case "bool_enum":
+			m, _ := t.Args.(map[string]any)
+			kind = uniformKind(m["true_value"], m["false_value"])
+		}
+	}
+
+	return kind, nullable
+}
@@ -1,10 +1,16 @@
 package jsync
 
-import "reflect"
+import (
+	"github.com/alanwgt/jsync/internal/config"
+	"reflect"
+	"sort"
+	"strings"
+)
 
 type tagProp struct {
-	name string
-	t    reflect.Type
+	name  string
+	t     reflect.Type
+	index int
 }
 
 func (tp tagProp) Name() string {
@@ -29,10 +35,83 @@ func extractTagMap(obj any) map[string]tagProp {
 		}
 
 		m[jsonV] = tagProp{
-			name: f.Name,
-			t:    f.Type,
+			name:  f.Name,
+			t:     f.Type,
+			index: i,
 		}
 	}
 
 	return m
 }
+
+type mappedField struct {
+	key        string
+	column     string
+	sqlType    string
+	transforms []config.Transform
+	prop       tagProp
+	hideable   bool // no modo público, recebe nulo se o campo estiver oculto no anúncio
+	private    bool // coluna privada do modo público, sempre com o valor real
+}
+
+// kind retorna como o campo é escrito no banco, considerando as transformações configuradas.
+func (f mappedField) kind() (sqlKind, bool) {
+	kind, nullable := kindOf(f.prop.Type())
+	return transformedKind(kind, nullable || f.hideable, f.transforms)
+}
+
+// mapFields cruza os campos do modelo com o mapeamento de colunas, ignorando as colunas calculadas. A chave do mapeamento é o último segmento da tag
+// `json` (campos aninhados como `rural.atividade_rural` são mapeados por `atividade_rural`). As chaves sem mapeamento
+// são retornadas em unmapped.
+func mapFields(obj any, colMap map[string]any) (fields []mappedField, unmapped []string, err error) {
+	for tag, prop := range extractTagMap(obj) {
+		tagSplit := strings.Split(tag, ".")
+		key := tagSplit[len(tagSplit)-1]
+
+		v, ok := colMap[key]
+		if !ok {
+			unmapped = append(unmapped, key)
+			continue
+		}
+
+		cm, err := config.ParseColumnMapping(key, v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if cm.Computed() {
+			// o valor da coluna é calculado (computedColumns), não vem do campo
+			continue
+		}
+
+		fields = append(fields, mappedField{
+			key:        key,
+			column:     cm.Column,
+			sqlType:    cm.Type,
+			transforms: cm.Transforms,
+			prop:       prop,
+		})
+	}
+
+	sort.Slice(fields, func(i, k int) bool {
+		return fields[i].key < fields[k].key
+	})
+	sort.Strings(unmapped)
+
+	return
+}
+
+// columnName retorna a coluna para a qual o campo está mapeado.
+func columnName(colMap map[string]any, field string) (string, bool) {
+	v, ok := colMap[field]
+	if !ok {
+		return "", false
+	}
+
+	cm, err := config.ParseColumnMapping(field, v)
+	if err != nil {
+		return "", false
+	}
+
+	return cm.Column, true
+}
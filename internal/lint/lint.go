@@ -0,0 +1,320 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/model"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Finding é um problema de qualidade encontrado em um imóvel ou condomínio.
+type Finding struct {
+	Resource string `json:"resource"`
+	Id       int    `json:"id"`
+	Code     string `json:"code,omitempty"` // código do imóvel ou nome do condomínio
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// issue é um problema encontrado por uma regra no item de índice index.
+type issue struct {
+	index   int
+	message string
+}
+
+// Rule é uma regra de qualidade. As regras que não se aplicam a um dos recursos deixam a função correspondente nil.
+type Rule struct {
+	Id          string
+	Severity    string // severidade padrão
+	Description string
+
+	properties   func(ps []model.Property, now time.Time) []issue
+	condominiums func(cs []model.Condominium) []issue
+}
+
+// Rules são as regras disponíveis, na ordem em que são executadas.
+var Rules = []Rule{
+	{
+		Id:          "no_images",
+		Severity:    config.SeverityWarning,
+		Description: "imóvel ou condomínio sem imagens",
+		properties: eachProperty(func(p model.Property, _ time.Time) (string, bool) {
+			return "imóvel sem imagens", !hasImages(p.Images)
+		}),
+		condominiums: eachCondominium(func(c model.Condominium) (string, bool) {
+			for _, img := range c.Images {
+				if img.Url != "" {
+					return "", false
+				}
+			}
+
+			return "condomínio sem imagens", true
+		}),
+	},
+	{
+		Id:          "missing_coordinates",
+		Severity:    config.SeverityWarning,
+		Description: "imóvel ou condomínio sem latitude e longitude válidas",
+		properties: eachProperty(func(p model.Property, _ time.Time) (string, bool) {
+			valid := p.Latitude.Valid && p.Longitude.Valid && validCoordinates(p.Latitude.Float64, p.Longitude.Float64)
+			return "imóvel sem coordenadas válidas, não é exibido em mapas", !valid
+		}),
+		condominiums: eachCondominium(func(c model.Condominium) (string, bool) {
+			return "condomínio sem coordenadas válidas, não é exibido em mapas", !validCoordinates(c.Latitude, c.Longitude)
+		}),
+	},
+	{
+		Id:          "hidden_price",
+		Severity:    config.SeverityError,
+		Description: "imóvel com o valor de venda oculto e sem valor de locação ou temporada visível",
+		properties: eachProperty(func(p model.Property, _ time.Time) (string, bool) {
+			visible := p.Visibility()
+			hidden := p.SaleValue.Float64 > 0 && !visible.SaleValue &&
+				!(visible.RentalValue && p.RentalValue.Float64 > 0) &&
+				!(visible.SeasonalValue && p.SeasonalValue.Float64 > 0)
+
+			return "valor de venda oculto e nenhum valor de locação ou temporada visível, o anúncio é exibido sem preço", hidden
+		}),
+	},
+	{
+		Id:          "empty_description",
+		Severity:    config.SeverityWarning,
+		Description: "imóvel sem descrição do anúncio",
+		properties: eachProperty(func(p model.Property, _ time.Time) (string, bool) {
+			return "descrição do anúncio vazia", strings.TrimSpace(p.AdDescription.String) == ""
+		}),
+	},
+	{
+		Id:          "duplicated_code",
+		Severity:    config.SeverityError,
+		Description: "código do imóvel utilizado por mais de um imóvel",
+		properties: func(ps []model.Property, _ time.Time) []issue {
+			byCode := make(map[string][]int)
+			for i, p := range ps {
+				if code := strings.TrimSpace(p.IdentifierCode); code != "" {
+					byCode[strings.ToLower(code)] = append(byCode[strings.ToLower(code)], i)
+				}
+			}
+
+			var issues []issue
+			for _, indexes := range byCode {
+				if len(indexes) < 2 {
+					continue
+				}
+
+				for _, i := range indexes {
+					var others []string
+					for _, o := range indexes {
+						if o != i {
+							others = append(others, strconv.Itoa(ps[o].Id))
+						}
+					}
+
+					issues = append(issues, issue{i, fmt.Sprintf(
+						`código "%s" também utilizado pelos imóveis %s`, ps[i].IdentifierCode, strings.Join(others, ", "),
+					)})
+				}
+			}
+
+			return issues
+		},
+	},
+	{
+		Id:          "expired_featured",
+		Severity:    config.SeverityInfo,
+		Description: "imóvel em destaque com o fim do destaque no passado",
+		properties: eachProperty(func(p model.Property, now time.Time) (string, bool) {
+			expired := bool(p.Featured) && p.FeatureUntil.Valid && p.FeatureUntil.Time.Before(now)
+			return fmt.Sprintf("imóvel em destaque com o destaque encerrado em %s", p.FeatureUntil.Time.Format("02/01/2006")), expired
+		}),
+	},
+	{
+		Id:          "zero_area",
+		Severity:    config.SeverityWarning,
+		Description: "imóvel sem nenhuma área (total, privativa, útil ou do terreno) informada",
+		properties: eachProperty(func(p model.Property, _ time.Time) (string, bool) {
+			for _, a := range []float64{p.TotalArea.Float64, p.PrivateArea.Float64, p.UsefulArea.Float64, p.TerrainArea.Float64} {
+				if a > 0 {
+					return "", false
+				}
+			}
+
+			return "nenhuma área do imóvel informada", true
+		}),
+	},
+}
+
+func eachProperty(f func(p model.Property, now time.Time) (string, bool)) func([]model.Property, time.Time) []issue {
+	return func(ps []model.Property, now time.Time) []issue {
+		var issues []issue
+		for i, p := range ps {
+			if msg, found := f(p, now); found {
+				issues = append(issues, issue{i, msg})
+			}
+		}
+
+		return issues
+	}
+}
+
+func eachCondominium(f func(c model.Condominium) (string, bool)) func([]model.Condominium) []issue {
+	return func(cs []model.Condominium) []issue {
+		var issues []issue
+		for i, c := range cs {
+			if msg, found := f(c); found {
+				issues = append(issues, issue{i, msg})
+			}
+		}
+
+		return issues
+	}
+}
+
+func hasImages(images model.MediaArray) bool {
+	for _, img := range images {
+		if img.Url != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validCoordinates(lat, lng float64) bool {
+	return !(lat == 0 && lng == 0) && math.Abs(lat) <= 90 && math.Abs(lng) <= 180
+}
+
+// severityRank ordena as severidades da mais para a menos grave.
+var severityRank = map[string]int{
+	config.SeverityError:   0,
+	config.SeverityWarning: 1,
+	config.SeverityInfo:    2,
+}
+
+// AtLeast indica se a severidade é tão grave quanto min.
+func AtLeast(severity string, min string) bool {
+	r, ok := severityRank[severity]
+	m, mok := severityRank[min]
+	return ok && mok && r <= m
+}
+
+// ValidSeverity indica se s é uma severidade de finding (error, warning ou info).
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// Linter executa as regras com as severidades configuradas.
+type Linter struct {
+	severities map[string]string
+	now        func() time.Time
+}
+
+// New valida a configuração, rejeitando regras e severidades desconhecidas.
+func New(cfg config.Lint) (Linter, error) {
+	l := Linter{severities: make(map[string]string, len(Rules)), now: time.Now}
+	for _, r := range Rules {
+		l.severities[r.Id] = r.Severity
+	}
+
+	for id, severity := range cfg.Rules {
+		if _, ok := l.severities[id]; !ok {
+			return Linter{}, errors.New(fmt.Sprintf(`lint: a regra "%s" não existe`, id))
+		}
+
+		severity = strings.ToLower(severity)
+		if !ValidSeverity(severity) && severity != config.SeverityOff {
+			return Linter{}, errors.New(fmt.Sprintf(`lint: a severidade "%s" da regra "%s" é inválida, utilize error, warning, info ou off`, severity, id))
+		}
+
+		l.severities[id] = severity
+	}
+
+	return l, nil
+}
+
+// Run executa as regras habilitadas nos imóveis e condomínios e retorna os findings ordenados por severidade, recurso
+// e id.
+func (l Linter) Run(ps []model.Property, cs []model.Condominium) []Finding {
+	now := l.now()
+
+	var findings []Finding
+	for _, r := range Rules {
+		severity := l.severities[r.Id]
+		if severity == config.SeverityOff {
+			continue
+		}
+
+		if r.properties != nil {
+			for _, i := range r.properties(ps, now) {
+				findings = append(findings, Finding{
+					Resource: config.ResourceProperties,
+					Id:       ps[i.index].Id,
+					Code:     ps[i.index].IdentifierCode,
+					Rule:     r.Id,
+					Severity: severity,
+					Message:  i.message,
+				})
+			}
+		}
+
+		if r.condominiums != nil {
+			for _, i := range r.condominiums(cs) {
+				findings = append(findings, Finding{
+					Resource: config.ResourceCondominiums,
+					Id:       cs[i.index].Id,
+					Code:     cs[i.index].Name,
+					Rule:     r.Id,
+					Severity: severity,
+					Message:  i.message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(a, b int) bool {
+		fa, fb := findings[a], findings[b]
+		if fa.Severity != fb.Severity {
+			return severityRank[fa.Severity] < severityRank[fb.Severity]
+		}
+
+		if fa.Resource != fb.Resource {
+			return fa.Resource > fb.Resource // imóveis antes dos condomínios
+		}
+
+		return fa.Id < fb.Id
+	})
+
+	return findings
+}
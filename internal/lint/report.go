@@ -0,0 +1,117 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package lint
+
+import (
+	j "encoding/json"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Report são os findings de um tenant.
+type Report struct {
+	Tenant   string         `json:"tenant,omitempty"`
+	Summary  map[string]int `json:"summary"` // quantidade de findings por severidade
+	Findings []Finding      `json:"findings"`
+}
+
+func NewReport(tenant string, findings []Finding) Report {
+	r := Report{
+		Tenant:   tenant,
+		Summary:  map[string]int{config.SeverityError: 0, config.SeverityWarning: 0, config.SeverityInfo: 0},
+		Findings: findings,
+	}
+
+	if r.Findings == nil {
+		r.Findings = []Finding{}
+	}
+
+	for _, f := range findings {
+		r.Summary[f.Severity]++
+	}
+
+	return r
+}
+
+// Has indica se o relatório possui findings com severidade igual ou mais grave que min.
+func (r Report) Has(min string) bool {
+	for _, f := range r.Findings {
+		if AtLeast(f.Severity, min) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteJSON escreve os relatórios como um array JSON.
+func WriteJSON(w io.Writer, reports []Report) error {
+	enc := j.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// WriteTable escreve os relatórios como tabelas, uma por tenant, seguidas do resumo.
+func WriteTable(w io.Writer, reports []Report) error {
+	for i, r := range reports {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		if r.Tenant != "" {
+			if _, err := fmt.Fprintf(w, "tenant %s\n", r.Tenant); err != nil {
+				return err
+			}
+		}
+
+		if len(r.Findings) > 0 {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "SEVERIDADE\tRECURSO\tID\tCÓDIGO\tREGRA\tMENSAGEM")
+			for _, f := range r.Findings {
+				fmt.Fprintln(tw, f.Severity+"\t"+f.Resource+"\t"+strconv.Itoa(f.Id)+"\t"+f.Code+"\t"+f.Rule+"\t"+f.Message)
+			}
+
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(
+			w, "%d erro(s), %d aviso(s), %d informação(ões)\n",
+			r.Summary[config.SeverityError], r.Summary[config.SeverityWarning], r.Summary[config.SeverityInfo],
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFileSize limita o tamanho dos arquivos baixados.
+const maxFileSize = 64 << 20
+
+var extensionRegex = regexp.MustCompile(`^\.[a-z0-9]{1,5}$`)
+
+// Mirror baixa arquivos de URLs externas para um Storage. A chave de cada arquivo é derivada da URL de origem, então
+// arquivos já armazenados (inclusive por uma execução interrompida) não são baixados novamente.
+type Mirror struct {
+	storage      Storage
+	client       *http.Client
+	baseUrl      string
+	prefix       string
+	concurrency  int
+	variantSpecs []config.MediaVariant
+	specs        string // hash de variantSpecs
+}
+
+// Source é uma URL a ser espelhada. Variants indica se as variantes da imagem devem ser geradas.
+type Source struct {
+	Url      string
+	Variants bool
+}
+
+// Mirrored é o resultado do espelhamento de uma URL.
+type Mirrored struct {
+	Url      string
+	Variants []Variant
+}
+
+func NewMirror(cfg config.Media) (*Mirror, error) {
+	if cfg.BaseUrl == "" {
+		return nil, errors.New("a URL pública das mídias (media.base_url) não foi configurada")
+	}
+
+	s, err := NewStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	specs, err := variantSpecs(cfg.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = config.DefaultMediaConcurrency
+	}
+
+	return &Mirror{
+		storage:      s,
+		client:       &http.Client{Timeout: time.Minute},
+		baseUrl:      strings.TrimSuffix(cfg.BaseUrl, "/"),
+		prefix:       strings.Trim(cfg.Prefix, "/"),
+		concurrency:  concurrency,
+		variantSpecs: specs,
+		specs:        specsHash(specs),
+	}, nil
+}
+
+// Key retorna a chave do arquivo espelhado a partir da URL de origem: o sha256 da URL, mantendo a extensão do arquivo e
+// distribuído em subdiretórios pelos dois primeiros caracteres.
+func (m Mirror) Key(src string) string {
+	h := sha256.Sum256([]byte(src))
+	name := hex.EncodeToString(h[:])
+
+	if u, err := url.Parse(src); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); extensionRegex.MatchString(ext) {
+			name += ext
+		}
+	}
+
+	key := path.Join(name[:2], name)
+	if m.prefix != "" {
+		key = path.Join(m.prefix, key)
+	}
+
+	return key
+}
+
+// Url retorna a URL pública do arquivo com a chave fornecida.
+func (m Mirror) Url(key string) string {
+	return fmt.Sprintf("%s/%s", m.baseUrl, key)
+}
+
+// Mirrored indica se a URL já aponta para o storage.
+func (m Mirror) Mirrored(src string) bool {
+	return strings.HasPrefix(src, m.baseUrl+"/")
+}
+
+// Mirror espelha as URLs concorrentemente, retornando a nova URL e as variantes de cada URL espelhada com sucesso. URLs
+// repetidas são baixadas apenas uma vez e as URLs que falharem não fazem parte do resultado. Se as variantes de uma
+// imagem não puderem ser geradas, a imagem é espelhada sem variantes.
+func (m Mirror) Mirror(l zerolog.Logger, sources []Source) map[string]Mirrored {
+	st := time.Now()
+	pending := make(chan Source)
+	result := make(map[string]Mirrored)
+	var stored, skipped, failed int
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for w := 0; w < m.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range pending {
+				src := s.Url
+				key := m.Key(src)
+				data, err := m.mirror(src, key)
+				if err != nil {
+					l.Error().Err(err).Str("url", src).Msg("falha ao espelhar mídia, mantendo a URL original")
+					mu.Lock()
+					failed++
+					mu.Unlock()
+					continue
+				}
+
+				var vs []Variant
+				if s.Variants && len(m.variantSpecs) > 0 {
+					if vs, err = m.variants(key, data); err != nil {
+						l.Warn().Err(err).Str("url", src).Msg("falha ao gerar variantes da mídia")
+					}
+				}
+
+				mu.Lock()
+				if data != nil {
+					stored++
+				} else {
+					skipped++
+				}
+				result[src] = Mirrored{Url: m.Url(key), Variants: vs}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// uma URL repetida gera variantes se alguma das ocorrências precisar delas
+	unique := make(map[string]bool)
+	var ordered []string
+	for _, s := range sources {
+		if s.Url == "" || m.Mirrored(s.Url) {
+			continue
+		}
+
+		if _, ok := unique[s.Url]; !ok {
+			ordered = append(ordered, s.Url)
+		}
+		unique[s.Url] = unique[s.Url] || s.Variants
+	}
+
+	for _, src := range ordered {
+		pending <- Source{Url: src, Variants: unique[src]}
+	}
+
+	close(pending)
+	wg.Wait()
+
+	l.Info().
+		Int("stored", stored).
+		Int("skipped", skipped).
+		Int("failed", failed).
+		Str("duração", time.Now().Sub(st).Round(time.Millisecond).String()).
+		Msg("mídias espelhadas")
+
+	return result
+}
+
+// mirror armazena o arquivo da URL se ele ainda não existir no storage, retornando o conteúdo baixado. Se o arquivo
+// já existir, o conteúdo retornado é nil.
+func (m Mirror) mirror(src, key string) ([]byte, error) {
+	exists, err := m.storage.Exists(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, nil
+	}
+
+	data, contentType, err := m.download(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.storage.Put(key, data, contentType); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (m Mirror) download(src string) ([]byte, string, error) {
+	res, err := m.client.Get(src)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", errors.New(fmt.Sprintf("download retornou status code %d", res.StatusCode))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxFileSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(data) > maxFileSize {
+		return nil, "", errors.New(fmt.Sprintf("o arquivo excede o tamanho máximo de %d bytes", maxFileSize))
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return data, contentType, nil
+}
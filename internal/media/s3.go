@@ -0,0 +1,241 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package media
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3Service       = "s3"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	s3EmptyBodyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256 de um corpo vazio
+)
+
+// S3Storage armazena os arquivos num bucket compatível com S3. As requisições são assinadas com o Signature Version 4.
+type S3Storage struct {
+	cfg      config.MediaS3
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewS3Storage(cfg config.MediaS3) (*S3Storage, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New(fmt.Sprintf(`endpoint s3 "%s" inválido, informe o esquema e o host (ex.: https://s3.amazonaws.com)`, cfg.Endpoint))
+	}
+
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: u,
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (s S3Storage) objectUrl(key string) *url.URL {
+	u := *s.endpoint
+	escapedKey := s3Escape(key)
+	if s.cfg.PathStyle {
+		u.Path = fmt.Sprintf("%s/%s/%s", u.Path, s.cfg.Bucket, key)
+		u.RawPath = fmt.Sprintf("%s/%s/%s", s.endpoint.EscapedPath(), s3Escape(s.cfg.Bucket), escapedKey)
+	} else {
+		u.Host = fmt.Sprintf("%s.%s", s.cfg.Bucket, u.Host)
+		u.Path = fmt.Sprintf("%s/%s", u.Path, key)
+		u.RawPath = fmt.Sprintf("%s/%s", s.endpoint.EscapedPath(), escapedKey)
+	}
+
+	return &u
+}
+
+func (s S3Storage) Exists(key string) (bool, error) {
+	res, err := s.do(http.MethodHead, key, nil, "")
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	return false, errors.New(fmt.Sprintf(`s3 respondeu HEAD "%s" com status code %d`, key, res.StatusCode))
+}
+
+func (s S3Storage) Put(key string, data []byte, contentType string) error {
+	res, err := s.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.New(fmt.Sprintf(`s3 respondeu PUT "%s" com status code %d: %s`, key, res.StatusCode, strings.TrimSpace(string(body))))
+	}
+
+	return nil
+}
+
+func (s S3Storage) Get(key string) ([]byte, error) {
+	res, err := s.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(res.Body)
+	case http.StatusNotFound:
+		return nil, fs.ErrNotExist
+	}
+
+	return nil, errors.New(fmt.Sprintf(`s3 respondeu GET "%s" com status code %d`, key, res.StatusCode))
+}
+
+func (s S3Storage) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	u := s.objectUrl(key)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(req, body, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign adiciona os headers de autenticação do Signature Version 4 à requisição.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := s3EmptyBodyHash
+	if len(body) > 0 {
+		payloadHash = hexSha256(body)
+	}
+
+	amzDate := now.Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.cfg.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hexSha256([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+s.cfg.SecretKey), now.Format(s3DateFormat))
+	key = hmacSha256(key, s.cfg.Region)
+	key = hmacSha256(key, s3Service)
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm,
+		s.cfg.AccessKey,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+// s3Escape codifica cada segmento da chave conforme o SigV4: apenas os caracteres não reservados ficam sem codificação.
+func s3Escape(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		var b strings.Builder
+		for _, c := range []byte(seg) {
+			if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+				b.WriteByte(c)
+			} else {
+				b.WriteString(fmt.Sprintf("%%%02X", c))
+			}
+		}
+
+		segments[i] = b.String()
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func hexSha256(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
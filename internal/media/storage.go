@@ -0,0 +1,76 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package media
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"os"
+)
+
+// Storage armazena os arquivos espelhados. As chaves são caminhos relativos separados por "/".
+type Storage interface {
+	// Exists indica se o arquivo já foi armazenado.
+	Exists(key string) (bool, error)
+	// Put armazena o conteúdo do arquivo. Um arquivo só passa a existir depois de armazenado por completo.
+	Put(key string, data []byte, contentType string) error
+	// Get retorna o conteúdo do arquivo. Se o arquivo não existir, o erro é fs.ErrNotExist.
+	Get(key string) ([]byte, error)
+}
+
+// NewStorage cria o storage configurado.
+func NewStorage(cfg config.Media) (Storage, error) {
+	switch cfg.Storage {
+	case config.MediaStorageLocal:
+		if cfg.Local.Path == "" {
+			return nil, errors.New("o caminho do storage local (media.local.path) não foi configurado")
+		}
+
+		return NewLocalStorage(cfg.Local.Path), nil
+	case config.MediaStorageS3:
+		s3 := cfg.S3
+		if s3.AccessKey == "" {
+			s3.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+
+		if s3.SecretKey == "" {
+			s3.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+
+		if s3.Endpoint == "" || s3.Bucket == "" || s3.Region == "" {
+			return nil, errors.New("o storage s3 precisa das opções media.s3.endpoint, media.s3.bucket e media.s3.region")
+		}
+
+		if s3.AccessKey == "" || s3.SecretKey == "" {
+			return nil, errors.New("as credenciais do storage s3 não foram configuradas")
+		}
+
+		return NewS3Storage(s3)
+	}
+
+	return nil, errors.New(fmt.Sprintf(`storage "%s" desconhecido, utilize "%s" ou "%s"`, cfg.Storage, config.MediaStorageLocal, config.MediaStorageS3))
+}
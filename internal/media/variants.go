@@ -0,0 +1,299 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/shell"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var variantNameRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var contentTypes = map[string]string{
+	config.MediaFormatJpeg: "image/jpeg",
+	config.MediaFormatPng:  "image/png",
+	config.MediaFormatWebp: "image/webp",
+}
+
+// Variant é uma versão redimensionada de um arquivo espelhado.
+type Variant struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	Url    string `json:"-"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+}
+
+// manifest registra as variantes geradas a partir de um arquivo. As variantes só são geradas novamente se o hash do
+// arquivo ou a configuração das variantes mudar.
+type manifest struct {
+	Source   string    `json:"source"` // sha256 do arquivo original
+	Specs    string    `json:"specs"`  // sha256 da configuração das variantes
+	Variants []Variant `json:"variants"`
+}
+
+// variantSpecs valida e normaliza a configuração das variantes. Variantes webp requerem o cwebp instalado.
+func variantSpecs(vs []config.MediaVariant) ([]config.MediaVariant, error) {
+	var specs []config.MediaVariant
+	names := make(map[string]bool)
+	for _, v := range vs {
+		if !variantNameRegex.MatchString(v.Name) {
+			return nil, errors.New(fmt.Sprintf(`nome de variante "%s" inválido, utilize apenas letras minúsculas, números, "-" e "_"`, v.Name))
+		}
+
+		if names[v.Name] {
+			return nil, errors.New(fmt.Sprintf(`variante "%s" duplicada`, v.Name))
+		}
+		names[v.Name] = true
+
+		if v.Width <= 0 && v.Height <= 0 {
+			return nil, errors.New(fmt.Sprintf(`variante "%s": informe width e/ou height`, v.Name))
+		}
+
+		if v.Format == "" {
+			v.Format = config.MediaFormatJpeg
+		}
+
+		if _, ok := contentTypes[v.Format]; !ok {
+			return nil, errors.New(fmt.Sprintf(`variante "%s": formato "%s" desconhecido, utilize jpeg, png ou webp`, v.Name, v.Format))
+		}
+
+		if v.Quality <= 0 || v.Quality > 100 {
+			v.Quality = config.DefaultMediaQuality
+		}
+
+		if v.Format == config.MediaFormatWebp {
+			if _, err := exec.LookPath("cwebp"); err != nil {
+				return nil, errors.New(fmt.Sprintf(`variante "%s": o formato webp requer o cwebp, que não foi encontrado no PATH`, v.Name))
+			}
+		}
+
+		specs = append(specs, v)
+	}
+
+	return specs, nil
+}
+
+func specsHash(specs []config.MediaVariant) string {
+	bs, _ := json.Marshal(specs)
+	return hexSha256(bs)
+}
+
+// manifestKey e variantKey derivam as chaves dos arquivos gerados a partir da chave do arquivo original.
+func manifestKey(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + ".variants.json"
+}
+
+func variantKey(key string, spec config.MediaVariant) string {
+	return fmt.Sprintf("%s_%s.%s", strings.TrimSuffix(key, path.Ext(key)), spec.Name, spec.Format)
+}
+
+// variants retorna as variantes do arquivo armazenado em key, gerando-as se necessário. data é o conteúdo do arquivo,
+// se ele acabou de ser baixado, ou nil.
+func (m Mirror) variants(key string, data []byte) ([]Variant, error) {
+	mkey := manifestKey(key)
+	man, err := m.loadManifest(mkey)
+	if err != nil {
+		return nil, err
+	}
+
+	if man != nil && man.Specs == m.specs && (data == nil || man.Source == hexSha256(data)) {
+		return m.withUrls(man.Variants), nil
+	}
+
+	if data == nil {
+		if data, err = m.storage.Get(key); err != nil {
+			return nil, err
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao decodificar imagem: %w", err)
+	}
+
+	man = &manifest{Source: hexSha256(data), Specs: m.specs}
+	for _, spec := range m.variantSpecs {
+		resized := resize(img, spec)
+		encoded, err := encode(resized, spec)
+		if err != nil {
+			return nil, fmt.Errorf(`variante "%s": %w`, spec.Name, err)
+		}
+
+		v := Variant{
+			Name:   spec.Name,
+			Key:    variantKey(key, spec),
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+			Format: spec.Format,
+		}
+
+		if err = m.storage.Put(v.Key, encoded, contentTypes[spec.Format]); err != nil {
+			return nil, err
+		}
+
+		man.Variants = append(man.Variants, v)
+	}
+
+	bs, err := json.Marshal(man)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.storage.Put(mkey, bs, "application/json"); err != nil {
+		return nil, err
+	}
+
+	return m.withUrls(man.Variants), nil
+}
+
+func (m Mirror) loadManifest(key string) (*manifest, error) {
+	bs, err := m.storage.Get(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	man := &manifest{}
+	if err = json.Unmarshal(bs, man); err != nil {
+		// um manifesto inválido é tratado como inexistente e será sobrescrito
+		return nil, nil
+	}
+
+	return man, nil
+}
+
+func (m Mirror) withUrls(vs []Variant) []Variant {
+	out := make([]Variant, len(vs))
+	for i, v := range vs {
+		v.Url = m.Url(v.Key)
+		out[i] = v
+	}
+
+	return out
+}
+
+// resize reduz a imagem, mantendo a proporção, para caber nas dimensões da variante.
+func resize(img image.Image, spec config.MediaVariant) image.Image {
+	b := img.Bounds()
+	scale := 1.0
+	if spec.Width > 0 && float64(spec.Width)/float64(b.Dx()) < scale {
+		scale = float64(spec.Width) / float64(b.Dx())
+	}
+
+	if spec.Height > 0 && float64(spec.Height)/float64(b.Dy()) < scale {
+		scale = float64(spec.Height) / float64(b.Dy())
+	}
+
+	w := int(float64(b.Dx())*scale + 0.5)
+	h := int(float64(b.Dy())*scale + 0.5)
+	if w < 1 {
+		w = 1
+	}
+
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if spec.Format == config.MediaFormatJpeg {
+		// jpeg não suporta transparência
+		draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+func encode(img image.Image, spec config.MediaVariant) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch spec.Format {
+	case config.MediaFormatJpeg:
+		err := jpeg.Encode(buf, img, &jpeg.Options{Quality: spec.Quality})
+		return buf.Bytes(), err
+	case config.MediaFormatPng:
+		err := png.Encode(buf, img)
+		return buf.Bytes(), err
+	case config.MediaFormatWebp:
+		return encodeWebp(img, spec.Quality)
+	}
+
+	return nil, errors.New(fmt.Sprintf(`formato "%s" desconhecido`, spec.Format))
+}
+
+// encodeWebp converte a imagem com o cwebp, já que a biblioteca padrão não possui um encoder webp.
+func encodeWebp(img image.Image, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "jsync-webp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.png")
+	out := filepath.Join(dir, "out.webp")
+
+	f, err := os.Create(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = png.Encode(f, img); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = shell.Exec("cwebp", "-quiet", "-q", strconv.Itoa(quality), in, "-o", out); err != nil {
+		return nil, fmt.Errorf("falha ao executar o cwebp: %w", err)
+	}
+
+	return os.ReadFile(out)
+}
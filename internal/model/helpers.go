@@ -39,16 +39,27 @@ type Model interface {
 	Identifier() int
 }
 
+// MediaVariant é uma versão redimensionada de uma imagem, gerada pelo espelhamento de mídias.
+type MediaVariant struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+}
+
 type Media struct {
-	Url          string      `json:"link"`
-	ThumbnailUrl string      `json:"link_thumb"`
-	Title        null.String `json:"titulo"`
+	Url          string         `json:"link"`
+	ThumbnailUrl string         `json:"link_thumb"`
+	Title        null.String    `json:"titulo"`
+	Variants     []MediaVariant `json:"-"`
 }
 
 type MappedMedia struct {
-	Url          string      `json:"url"`
-	ThumbnailUrl string      `json:"thumbnail_url"`
-	Title        null.String `json:"title"`
+	Url          string         `json:"url"`
+	ThumbnailUrl string         `json:"thumbnail_url"`
+	Title        null.String    `json:"title"`
+	Variants     []MediaVariant `json:"variants,omitempty"`
 }
 
 func (m Media) MarshalJSON() ([]byte, error) {
@@ -56,6 +67,7 @@ func (m Media) MarshalJSON() ([]byte, error) {
 		Url:          m.Url,
 		Title:        m.Title,
 		ThumbnailUrl: m.ThumbnailUrl,
+		Variants:     m.Variants,
 	})
 }
 
@@ -64,19 +76,22 @@ func (m Media) Value() (driver.Value, error) {
 }
 
 type CondominiumMedia struct {
-	Url   string      `json:"link"`
-	Title null.String `json:"titulo"`
+	Url      string         `json:"link"`
+	Title    null.String    `json:"titulo"`
+	Variants []MediaVariant `json:"-"`
 }
 
 type CondominiumMappedMedia struct {
-	Url   string      `json:"url"`
-	Title null.String `json:"title"`
+	Url      string         `json:"url"`
+	Title    null.String    `json:"title"`
+	Variants []MediaVariant `json:"variants,omitempty"`
 }
 
 func (cm CondominiumMedia) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&CondominiumMappedMedia{
-		Url:   cm.Url,
-		Title: cm.Title,
+		Url:      cm.Url,
+		Title:    cm.Title,
+		Variants: cm.Variants,
 	})
 }
 
@@ -0,0 +1,67 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package model
+
+// Visibility indica quais informações do imóvel podem ser publicadas, conforme os indicadores de visibilidade da
+// Jetimob. É a regra única dos feeds, dos dados estruturados e do modo público: o endereço completamente visível libera
+// todas as partes do endereço, e o CEP, o número e a referência dependem do logradouro.
+type Visibility struct {
+	SaleValue        bool
+	RentalValue      bool
+	SeasonalValue    bool
+	IptuValue        bool
+	CondominiumValue bool
+	State            bool
+	City             bool
+	Neighborhood     bool
+	Street           bool // também libera o CEP
+	Number           bool
+	Reference        bool
+	Floor            bool
+}
+
+// Visibility retorna a visibilidade das informações do imóvel.
+func (p Property) Visibility() Visibility {
+	address := func(show bool) bool {
+		return p.ShowFullAddress || show
+	}
+
+	street := address(p.ShowAddressStreet)
+	return Visibility{
+		SaleValue:        p.ShowSaleValue,
+		RentalValue:      p.ShowRentalValue,
+		SeasonalValue:    p.ShowSeasonalValue,
+		IptuValue:        p.ShowIptuValue,
+		CondominiumValue: p.ShowCondominiumValue,
+		State:            address(p.ShowAddressState),
+		City:             address(p.ShowAddressCity),
+		Neighborhood:     address(p.ShowAddressNeighborhood),
+		Street:           street,
+		Number:           street && address(p.ShowAddressNumber),
+		Reference:        street && address(p.ShowAddressReference),
+		Floor:            address(p.ShowAddressFloor),
+	}
+}
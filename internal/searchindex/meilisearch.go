@@ -0,0 +1,149 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package searchindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	meiliTaskPollInterval = 200 * time.Millisecond
+	meiliTaskTimeout      = 5 * time.Minute
+)
+
+// meilisearch utiliza a API HTTP do Meilisearch. As operações são assíncronas no Meilisearch, então cada operação
+// aguarda a conclusão da sua task para reportar erros.
+type meilisearch struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+type meiliTask struct {
+	TaskUid int64  `json:"taskUid"`
+	Uid     int64  `json:"uid"`
+	Status  string `json:"status"`
+	Error   *struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+func (m meilisearch) upsert(index, primaryKey string, docs []map[string]any) error {
+	return m.run(http.MethodPost, fmt.Sprintf("/indexes/%s/documents?primaryKey=%s", url.PathEscape(index), url.QueryEscape(primaryKey)), docs, false)
+}
+
+func (m meilisearch) delete(index string, ids []int) error {
+	return m.run(http.MethodPost, fmt.Sprintf("/indexes/%s/documents/delete-batch", url.PathEscape(index)), ids, true)
+}
+
+func (m meilisearch) clear(index string) error {
+	return m.run(http.MethodDelete, fmt.Sprintf("/indexes/%s/documents", url.PathEscape(index)), nil, true)
+}
+
+// run envia a requisição e aguarda a task criada. Se ignoreMissing for verdadeiro, índices inexistentes não são
+// considerados erros (remoções em índices que ainda não foram criados).
+func (m meilisearch) run(method, path string, body any, ignoreMissing bool) error {
+	var task meiliTask
+	if err := m.do(method, path, body, &task); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(meiliTaskTimeout)
+	for {
+		switch task.Status {
+		case "succeeded":
+			return nil
+		case "failed", "canceled":
+			if task.Error != nil && task.Error.Code == "index_not_found" && ignoreMissing {
+				return nil
+			}
+
+			if task.Error != nil {
+				return errors.New(fmt.Sprintf("meilisearch: a task %d falhou: %s (%s)", task.uid(), task.Error.Message, task.Error.Code))
+			}
+
+			return errors.New(fmt.Sprintf("meilisearch: a task %d foi cancelada", task.uid()))
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("meilisearch: tempo esgotado aguardando a task %d", task.uid()))
+		}
+
+		time.Sleep(meiliTaskPollInterval)
+		uid := task.uid()
+		task = meiliTask{}
+		if err := m.do(http.MethodGet, fmt.Sprintf("/tasks/%d", uid), nil, &task); err != nil {
+			return err
+		}
+	}
+}
+
+func (t meiliTask) uid() int64 {
+	if t.TaskUid != 0 {
+		return t.TaskUid
+	}
+
+	return t.Uid
+}
+
+func (m meilisearch) do(method, path string, body any, dst any) error {
+	var bs []byte
+	if body != nil {
+		var err error
+		if bs, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, m.url+path, bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return responseError("meilisearch", res)
+	}
+
+	return json.NewDecoder(res.Body).Decode(dst)
+}
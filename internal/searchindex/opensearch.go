@@ -0,0 +1,160 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package searchindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// opensearch utiliza a API HTTP do OpenSearch (compatível com o Elasticsearch). Os documentos são enviados pela API
+// _bulk, com o id da row como _id.
+type opensearch struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+type bulkResponse struct {
+	Errors bool                          `json:"errors"`
+	Items  []map[string]bulkItemResponse `json:"items"`
+}
+
+type bulkItemResponse struct {
+	Id     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func (o opensearch) upsert(index, primaryKey string, docs []map[string]any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, d := range docs {
+		id := fmt.Sprint(d[primaryKey])
+		if err := enc.Encode(map[string]any{"index": map[string]string{"_index": index, "_id": id}}); err != nil {
+			return err
+		}
+
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	}
+
+	return o.bulk(buf.Bytes())
+}
+
+func (o opensearch) delete(index string, ids []int) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		if err := enc.Encode(map[string]any{"delete": map[string]string{"_index": index, "_id": strconv.Itoa(id)}}); err != nil {
+			return err
+		}
+	}
+
+	return o.bulk(buf.Bytes())
+}
+
+func (o opensearch) clear(index string) error {
+	res, err := o.do(http.MethodPost, fmt.Sprintf("/%s/_delete_by_query?conflicts=proceed", url.PathEscape(index)), "application/json", []byte(`{"query":{"match_all":{}}}`))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return responseError("opensearch", res)
+	}
+
+	return nil
+}
+
+// bulk envia as operações e reporta os itens com erro. Remoções de documentos ou índices inexistentes são ignoradas.
+func (o opensearch) bulk(body []byte) error {
+	res, err := o.do(http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError("opensearch", res)
+	}
+
+	var br bulkResponse
+	if err = json.NewDecoder(res.Body).Decode(&br); err != nil {
+		return err
+	}
+
+	if !br.Errors {
+		return nil
+	}
+
+	var msgs []string
+	for _, item := range br.Items {
+		for op, r := range item {
+			if r.Error == nil || (op == "delete" && r.Error.Type == "index_not_found_exception") {
+				continue
+			}
+
+			msgs = append(msgs, fmt.Sprintf("%s %s: %s (%s)", op, r.Id, r.Error.Reason, r.Error.Type))
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("opensearch: %d operações falharam:\n%s", len(msgs), strings.Join(msgs, "\n")))
+}
+
+func (o opensearch) do(method, path, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, o.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	return o.client.Do(req)
+}
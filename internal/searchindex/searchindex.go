@@ -0,0 +1,130 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package searchindex
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// engine é a API de um motor de busca. Os documentos são enviados em lotes de até batchSize itens.
+type engine interface {
+	// upsert insere ou substitui os documentos do índice. primaryKey é o campo com o id dos documentos.
+	upsert(index, primaryKey string, docs []map[string]any) error
+	// delete remove os documentos com os ids fornecidos. Ids inexistentes são ignorados.
+	delete(index string, ids []int) error
+	// clear remove todos os documentos do índice.
+	clear(index string) error
+}
+
+// Client envia os documentos ao motor de busca configurado, dividindo-os em lotes.
+type Client struct {
+	engine    engine
+	batchSize int
+}
+
+// New cria o cliente do motor de busca configurado.
+func New(cfg config.SearchIndex) (*Client, error) {
+	if cfg.Url == "" {
+		return nil, errors.New("a URL do motor de busca (search_index.url) não foi configurada")
+	}
+
+	for r := range cfg.Resources {
+		if r != config.ResourceProperties && r != config.ResourceCondominiums {
+			return nil, errors.New(fmt.Sprintf(`search_index: recurso "%s" não pode ser indexado, utilize "%s" ou "%s"`, r, config.ResourceProperties, config.ResourceCondominiums))
+		}
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = config.DefaultSearchIndexBatchSize
+	}
+
+	httpClient := &http.Client{Timeout: time.Minute}
+	baseUrl := strings.TrimSuffix(cfg.Url, "/")
+
+	var e engine
+	switch cfg.Engine {
+	case config.SearchEngineMeilisearch:
+		e = meilisearch{baseUrl, cfg.ApiKey, httpClient}
+	case config.SearchEngineOpensearch:
+		e = opensearch{baseUrl, cfg.Username, cfg.Password, httpClient}
+	default:
+		return nil, errors.New(fmt.Sprintf(`motor de busca "%s" desconhecido, utilize "%s" ou "%s"`, cfg.Engine, config.SearchEngineMeilisearch, config.SearchEngineOpensearch))
+	}
+
+	return &Client{engine: e, batchSize: batchSize}, nil
+}
+
+func (c Client) Upsert(index, primaryKey string, docs []map[string]any) error {
+	for start := 0; start < len(docs); start += c.batchSize {
+		if err := c.engine.upsert(index, primaryKey, docs[start:min(start+c.batchSize, len(docs))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Client) Delete(index string, ids []int) error {
+	for start := 0; start < len(ids); start += c.batchSize {
+		if err := c.engine.delete(index, ids[start:min(start+c.batchSize, len(ids))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Client) Clear(index string) error {
+	return c.engine.clear(index)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// responseError monta o erro de uma resposta inesperada do motor de busca, com o início do corpo da resposta.
+func responseError(engine string, res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return errors.New(fmt.Sprintf(
+		`%s respondeu %s "%s" com status code %d: %s`,
+		engine,
+		res.Request.Method,
+		res.Request.URL.Path,
+		res.StatusCode,
+		strings.TrimSpace(string(body)),
+	))
+}
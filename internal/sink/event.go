@@ -0,0 +1,84 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package sink
+
+import (
+	"time"
+)
+
+const (
+	eventUpsert     = "upsert"
+	eventDelete     = "delete"
+	eventMarkActive = "mark_active"
+)
+
+// event é uma escrita registrada pelos sinks file e webhook. As rows são divididas em eventos de até batchSize itens.
+type event struct {
+	Event    string           `json:"event"` // upsert, delete ou mark_active
+	Resource string           `json:"resource"`
+	Tenant   string           `json:"tenant,omitempty"`
+	Truncate bool             `json:"truncate,omitempty"` // as rows substituem todos os itens do recurso
+	Ids      []int            `json:"ids"`
+	Rows     []map[string]any `json:"rows,omitempty"`
+	Time     time.Time        `json:"time"`
+}
+
+// events acumula os eventos de uma sincronização até o Commit.
+type events struct {
+	batchSize int
+	pending   []event
+}
+
+func (e *events) reset() {
+	e.pending = nil
+}
+
+func (e *events) upsert(r Resource, ids []int, rows []Row) {
+	now := time.Now()
+	for start := 0; start < len(rows); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		ev := event{Event: eventUpsert, Resource: r.Name, Tenant: r.Tenant, Ids: ids[start:end], Time: now}
+		// apenas o primeiro lote substitui os itens existentes
+		ev.Truncate = r.Truncate && start == 0
+		for _, row := range rows[start:end] {
+			ev.Rows = append(ev.Rows, Plain(row))
+		}
+
+		e.pending = append(e.pending, ev)
+	}
+}
+
+func (e *events) add(name string, r Resource, ids []int) {
+	if ids == nil {
+		ids = []int{}
+	}
+
+	e.pending = append(e.pending, event{Event: name, Resource: r.Name, Tenant: r.Tenant, Ids: ids, Time: time.Now()})
+}
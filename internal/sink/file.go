@@ -0,0 +1,123 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package sink
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/alanwgt/jsync/internal/config"
+	"os"
+	"path/filepath"
+)
+
+// file adiciona os eventos da sincronização, um por linha (NDJSON), ao arquivo <path>/<recurso>.ndjson ou, em ambientes
+// multi tenancy, <path>/<recurso>_<tenant>.ndjson. Os eventos só são gravados no Commit.
+type file struct {
+	path string
+	events
+}
+
+func newFile(cfg config.Sink) (*file, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("o diretório (path) do sink file não foi configurado")
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = config.DefaultSinkBatchSize
+	}
+
+	return &file{path: cfg.Path, events: events{batchSize: batchSize}}, nil
+}
+
+func (f *file) Begin() error {
+	f.reset()
+	return nil
+}
+
+func (f *file) Upsert(r Resource, ids []int, rows []Row) error {
+	f.upsert(r, ids, rows)
+	return nil
+}
+
+func (f *file) Delete(r Resource, ids []int) error {
+	f.add(eventDelete, r, ids)
+	return nil
+}
+
+func (f *file) MarkActive(r Resource, ids []int) error {
+	f.add(eventMarkActive, r, ids)
+	return nil
+}
+
+func (f *file) Commit() error {
+	defer f.reset()
+
+	if len(f.pending) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(f.path, 0o755); err != nil {
+		return err
+	}
+
+	for _, ev := range f.pending {
+		name := ev.Resource
+		if ev.Tenant != "" {
+			name += "_" + ev.Tenant
+		}
+
+		if err := appendEvent(filepath.Join(f.path, name+".ndjson"), ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *file) Rollback() error {
+	f.reset()
+	return nil
+}
+
+func appendEvent(name string, ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = out.Write(append(data, '\n')); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,89 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"github.com/alanwgt/jsync/internal/transform"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Resource identifica o recurso escrito no sink.
+type Resource struct {
+	Name     string // banners, brokers, condominiums ou properties
+	Table    string // tabela do recurso no banco de dados
+	IdColumn string // coluna com o id dos itens nas rows
+	Tenant   string // identificador do tenant atual, vazio fora de ambientes multi tenancy
+	Truncate bool   // a sincronização substitui todos os itens do recurso
+}
+
+// Row é um item sincronizado, com os nomes de colunas e as transformações do mapeamento. Os valores são os mesmos
+// gravados no banco de dados, utilize Plain para convertê-los em tipos básicos.
+type Row map[string]any
+
+// Sink é um destino dos dados sincronizados. Uma sincronização inicia com Begin e termina com Commit ou, em caso de
+// falha, com Rollback. As escritas feitas entre os dois só devem ser efetivadas no Commit.
+type Sink interface {
+	Begin() error
+	// Upsert insere ou substitui as rows do recurso. ids contém os ids das rows, na mesma ordem.
+	Upsert(r Resource, ids []int, rows []Row) error
+	// Delete remove os itens com os ids fornecidos. Ids inexistentes são ignorados.
+	Delete(r Resource, ids []int) error
+	// MarkActive marca como ativos apenas os itens com os ids fornecidos. Apenas os imóveis possuem o estado.
+	MarkActive(r Resource, ids []int) error
+	Commit() error
+	Rollback() error
+}
+
+// New cria o sink configurado. O sink postgres depende da conexão e das transações do jsync e não é criado aqui.
+func New(cfg config.Sink) (Sink, error) {
+	switch cfg.Type {
+	case config.SinkFile:
+		return newFile(cfg)
+	case config.SinkWebhook:
+		return newWebhook(cfg)
+	}
+
+	return nil, errors.New(fmt.Sprintf(`tipo de sink "%s" desconhecido, utilize "%s", "%s" ou "%s"`, cfg.Type, config.SinkPostgres, config.SinkFile, config.SinkWebhook))
+}
+
+// Plain converte os valores da row para os tipos básicos (string, int64, float64, bool, time.Time e []string). As
+// colunas escritas como expressões SQL (colunas calculadas com "sql" e a coluna de busca) só podem ser avaliadas pelo
+// banco de dados e são omitidas.
+func Plain(row Row) map[string]any {
+	m := make(map[string]any, len(row))
+	for k, v := range row {
+		if _, ok := v.(exp.Expression); ok {
+			continue
+		}
+
+		m[k] = transform.Unwrap(v)
+	}
+
+	return m
+}
@@ -0,0 +1,131 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhook envia os eventos da sincronização, um por requisição, como POST com corpo JSON para a URL configurada. Os
+// eventos só são enviados no Commit, na ordem em que foram registrados.
+type webhook struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	events
+}
+
+func newWebhook(cfg config.Sink) (*webhook, error) {
+	if cfg.Url == "" {
+		return nil, errors.New("a URL (url) do sink webhook não foi configurada")
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = config.DefaultSinkBatchSize
+	}
+
+	return &webhook{
+		url:     cfg.Url,
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: time.Minute},
+		events:  events{batchSize: batchSize},
+	}, nil
+}
+
+func (w *webhook) Begin() error {
+	w.reset()
+	return nil
+}
+
+func (w *webhook) Upsert(r Resource, ids []int, rows []Row) error {
+	w.upsert(r, ids, rows)
+	return nil
+}
+
+func (w *webhook) Delete(r Resource, ids []int) error {
+	w.add(eventDelete, r, ids)
+	return nil
+}
+
+func (w *webhook) MarkActive(r Resource, ids []int) error {
+	w.add(eventMarkActive, r, ids)
+	return nil
+}
+
+func (w *webhook) Commit() error {
+	defer w.reset()
+
+	for _, ev := range w.pending {
+		if err := w.send(ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *webhook) Rollback() error {
+	w.reset()
+	return nil
+}
+
+func (w *webhook) send(ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return errors.New(fmt.Sprintf(`webhook "%s" respondeu %d ao evento %s de %s: %s`, w.url, res.StatusCode, ev.Event, ev.Resource, bytes.TrimSpace(body)))
+	}
+
+	return nil
+}
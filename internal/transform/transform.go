@@ -0,0 +1,352 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/alanwgt/jsync/internal/config"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Func transforma um valor já normalizado por Unwrap.
+type Func func(v any) (any, error)
+
+type builder func(args any) (Func, error)
+
+var builders = map[string]builder{
+	"lowercase":   noArgs("lowercase", stringOp("lowercase", strings.ToLower)),
+	"uppercase":   noArgs("uppercase", stringOp("uppercase", strings.ToUpper)),
+	"trim":        noArgs("trim", stringOp("trim", strings.TrimSpace)),
+	"slug":        noArgs("slug", stringOp("slug", Slug)),
+	"map":         valueMap,
+	"default":     defaultValue,
+	"replace":     replace,
+	"scale":       scale,
+	"date_format": dateFormat,
+	"bool_enum":   boolEnum,
+	"split":       split,
+	"join":        join,
+}
+
+// Names retorna o nome de todas as transformações disponíveis.
+func Names() []string {
+	ns := make([]string, 0, len(builders))
+	for n := range builders {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+
+	return ns
+}
+
+func New(t config.Transform) (Func, error) {
+	b, ok := builders[t.Name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`transformação "%s" desconhecida, as disponíveis são: %s`, t.Name, strings.Join(Names(), ", ")))
+	}
+
+	f, err := b(t.Args)
+	if err != nil {
+		return nil, fmt.Errorf(`transformação "%s": %w`, t.Name, err)
+	}
+
+	return f, nil
+}
+
+// Pipeline compõe as transformações em ordem. O valor recebido é normalizado com Unwrap antes da primeira
+// transformação e o resultado é preparado para inserção com Wrap.
+func Pipeline(ts []config.Transform) (Func, error) {
+	fs := make([]Func, len(ts))
+	for i, t := range ts {
+		f, err := New(t)
+		if err != nil {
+			return nil, err
+		}
+
+		fs[i] = f
+	}
+
+	return func(v any) (any, error) {
+		v = Unwrap(v)
+		for _, f := range fs {
+			var err error
+			if v, err = f(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return Wrap(v), nil
+	}, nil
+}
+
+func unsupported(name string, v any) error {
+	return errors.New(fmt.Sprintf(`a transformação "%s" não aceita valores do tipo %T`, name, v))
+}
+
+func noArgs(name string, f Func) builder {
+	return func(args any) (Func, error) {
+		if args != nil {
+			return nil, errors.New(fmt.Sprintf(`"%s" não aceita argumentos`, name))
+		}
+
+		return f, nil
+	}
+}
+
+func stringOp(name string, op func(string) string) Func {
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return op(t), nil
+		case []string:
+			out := make([]string, len(t))
+			for i, s := range t {
+				out[i] = op(s)
+			}
+			return out, nil
+		}
+
+		return nil, unsupported(name, v)
+	}
+}
+
+func argString(args any) (string, error) {
+	s, ok := args.(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("o argumento precisa ser um texto, recebido: %v", args))
+	}
+
+	return s, nil
+}
+
+func argFloat(args any) (float64, error) {
+	switch t := args.(type) {
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case float64:
+		return t, nil
+	}
+
+	return 0, errors.New(fmt.Sprintf("o argumento precisa ser um número, recebido: %v", args))
+}
+
+func argMap(args any, required ...string) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("o argumento precisa ser um objeto, recebido: %v", args))
+	}
+
+	for _, k := range required {
+		if _, ok := m[k]; !ok {
+			return nil, errors.New(fmt.Sprintf(`a chave "%s" é obrigatória`, k))
+		}
+	}
+
+	return m, nil
+}
+
+// valueMap substitui valores conforme o objeto fornecido. As chaves são comparadas sem diferenciar maiúsculas de
+// minúsculas, já que o viper converte as chaves do arquivo de configuração para minúsculas.
+func valueMap(args any) (Func, error) {
+	m, err := argMap(args)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(v any) any {
+		if r, ok := m[strings.ToLower(fmt.Sprint(v))]; ok {
+			return r
+		}
+
+		return v
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case []string:
+			out := make([]string, len(t))
+			for i, s := range t {
+				out[i] = fmt.Sprint(lookup(s))
+			}
+			return out, nil
+		}
+
+		return lookup(v), nil
+	}, nil
+}
+
+func defaultValue(args any) (Func, error) {
+	return func(v any) (any, error) {
+		if v == nil || v == "" {
+			return args, nil
+		}
+
+		return v, nil
+	}, nil
+}
+
+func replace(args any) (Func, error) {
+	m, err := argMap(args, "pattern", "with")
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := argString(m["pattern"])
+	if err != nil {
+		return nil, err
+	}
+
+	with, err := argString(m["with"])
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return stringOp("replace", func(s string) string {
+		return re.ReplaceAllString(s, with)
+	}), nil
+}
+
+func scale(args any) (Func, error) {
+	factor, err := argFloat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case int64:
+			return float64(t) * factor, nil
+		case float64:
+			return t * factor, nil
+		}
+
+		return nil, unsupported("scale", v)
+	}, nil
+}
+
+// dateFormat formata datas com o layout do pacote time do go (ex.: 2006-01-02).
+func dateFormat(args any) (Func, error) {
+	layout, err := argString(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case time.Time:
+			if t.IsZero() {
+				return nil, nil
+			}
+
+			return t.Format(layout), nil
+		}
+
+		return nil, unsupported("date_format", v)
+	}, nil
+}
+
+func boolEnum(args any) (Func, error) {
+	m, err := argMap(args, "true_value", "false_value")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case bool:
+			if t {
+				return m["true_value"], nil
+			}
+
+			return m["false_value"], nil
+		}
+
+		return nil, unsupported("bool_enum", v)
+	}, nil
+}
+
+func split(args any) (Func, error) {
+	sep, err := argString(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			out := []string{}
+			for _, s := range strings.Split(t, sep) {
+				if s = strings.TrimSpace(s); s != "" {
+					out = append(out, s)
+				}
+			}
+			return out, nil
+		}
+
+		return nil, unsupported("split", v)
+	}, nil
+}
+
+func join(args any) (Func, error) {
+	sep, err := argString(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) (any, error) {
+		switch t := v.(type) {
+		case nil:
+			return nil, nil
+		case []string:
+			return strings.Join(t, sep), nil
+		}
+
+		return nil, unsupported("join", v)
+	}, nil
+}
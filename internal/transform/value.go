@@ -0,0 +1,103 @@
+// Copyright © 2022 Alan Weingartner <hi@alanwgt.com>
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice,
+//     this list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+// ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+// LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+// CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+// SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+// INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+// CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+// ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+// POSSIBILITY OF SUCH DAMAGE.
+
+package transform
+
+import (
+	"github.com/alanwgt/jsync/internal/json"
+	"gopkg.in/guregu/null.v4"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Unwrap converte os tipos dos modelos para tipos básicos: nil, string, []string, int64, float64, bool ou time.Time.
+// Valores de outros tipos (como vetores de mídias) são retornados sem modificação.
+func Unwrap(v any) any {
+	switch t := v.(type) {
+	case nil, string, []string, int64, float64, bool, time.Time:
+		return t
+	case null.String:
+		return nullable(t.Valid, t.String)
+	case json.NullEmptyString:
+		return nullable(t.Valid, t.String)
+	case null.Int:
+		return nullable(t.Valid, t.Int64)
+	case null.Float:
+		return nullable(t.Valid, t.Float64)
+	case null.Bool:
+		return nullable(t.Valid, t.Bool)
+	case null.Time:
+		return nullable(t.Valid, t.Time)
+	case json.JTime:
+		return t.Time
+	case json.CommaStrSlice:
+		return trimmed(t)
+	case json.StrSlice:
+		return trimmed(t)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	}
+
+	return v
+}
+
+// Wrap prepara um valor retornado pelas transformações para ser inserido pelo goqu.
+func Wrap(v any) any {
+	if ss, ok := v.([]string); ok {
+		return json.StrSlice(ss)
+	}
+
+	return v
+}
+
+func nullable(valid bool, v any) any {
+	if !valid {
+		return nil
+	}
+
+	return v
+}
+
+func trimmed(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = strings.TrimSpace(s)
+	}
+
+	return out
+}
@@ -0,0 +1,32 @@
+UPDATE properties SET state_id = 0 WHERE state_id IS NULL;
+UPDATE properties SET city_id = 0 WHERE city_id IS NULL;
+UPDATE properties SET neighborhood_id = 0 WHERE neighborhood_id IS NULL;
+UPDATE properties SET address_state = '' WHERE address_state IS NULL;
+UPDATE properties SET address_city = '' WHERE address_city IS NULL;
+UPDATE properties SET address_neighborhood = '' WHERE address_neighborhood IS NULL;
+UPDATE properties SET address_street = '' WHERE address_street IS NULL;
+UPDATE properties SET address_number = '' WHERE address_number IS NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN state_id SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN city_id SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN neighborhood_id SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_state SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_city SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_neighborhood SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_street SET NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_number SET NOT NULL;
@@ -0,0 +1,23 @@
+ALTER TABLE properties
+  ALTER COLUMN state_id DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN city_id DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN neighborhood_id DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_state DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_city DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_neighborhood DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_street DROP NOT NULL;
+
+ALTER TABLE properties
+  ALTER COLUMN address_number DROP NOT NULL;